@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/tmux"
+)
+
+// attachSession attaches the terminal to a session, or switches the
+// current client to it when hive runs inside tmux
+func attachSession(ctx context.Context, client *tmux.Client, sessionName string) {
+	server := tmuxInvocation(client)
+
+	inTmux := os.Getenv("TMUX") != ""
+	if inTmux && !sameServer(ctx, client) {
+		// A client can only switch between sessions of its own server
+		logger.Infof("Session '%s' runs on another tmux server", sessionName)
+		logger.Infof("Attach from outside tmux with: %s attach -t %s", server, sessionName)
+		return
+	}
+
+	switchCmd := client.Command("attach", "-t", sessionName)
+	if inTmux {
+		// Switch to the new session instead of attaching
+		switchCmd = client.Command("switch-client", "-t", sessionName)
+	}
+
+	if err := switchCmd.Run(); err != nil {
+		logger.Warnf("Failed to attach/switch to session (session created successfully): %v", err)
+		if inTmux {
+			logger.Infof("Switch manually with: %s switch-client -t %s", server, sessionName)
+		} else {
+			logger.Infof("Attach manually with: %s attach -t %s", server, sessionName)
+		}
+	}
+}
+
+// sameServer reports whether the client targets the server hive runs inside
+func sameServer(ctx context.Context, client *tmux.Client) bool {
+	if !client.CustomServer() {
+		return true
+	}
+
+	socket, err := client.ServerSocket(ctx)
+	if err != nil {
+		return false
+	}
+	return socket == strings.SplitN(os.Getenv("TMUX"), ",", 2)[0]
+}
+
+// tmuxInvocation is the tmux command line a user would type to reach the
+// client's server, for hints printed to the terminal
+func tmuxInvocation(client *tmux.Client) string {
+	return strings.Join(append([]string{"tmux"}, client.ServerArgs()...), " ")
+}
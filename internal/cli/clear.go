@@ -23,6 +23,8 @@ func init() {
 }
 
 func runClear(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
 	// Discover config file
 	configPath, err := config.Discover(cfgFile)
 	if err != nil {
@@ -38,8 +40,10 @@ func runClear(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	client := tmux.NewSessionClient(cfg.Session)
+
 	// Check if session exists
-	if !tmux.SessionExists(cfg.Session.Name) {
+	if !client.SessionExists(ctx, cfg.Session.Name) {
 		logger.Infof("Session '%s' does not exist", cfg.Session.Name)
 		return nil
 	}
@@ -66,8 +70,9 @@ func runClear(cmd *cobra.Command, args []string) error {
 
 	// Kill the session
 	logger.Infof("Killing session '%s'", cfg.Session.Name)
-	if err := tmux.KillSession(cfg.Session.Name); err != nil {
+	if err := client.KillSession(ctx, cfg.Session.Name); err != nil {
 		logger.Error("Failed to kill session")
+		logHint(client, err)
 		return err
 	}
 
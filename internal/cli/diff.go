@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffMissingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffExtraStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffModifiedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	diffHeaderStyle   = lipgloss.NewStyle().Bold(true)
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how the running session has drifted from the config",
+	Long: `Compare the hive configuration with its running tmux session.
+
+Reports windows, panes, layouts, working directories, options and environment
+variables that differ. Nothing is changed.
+
+Exits with status 2 when the session differs from the config, so it can be
+used as a check in scripts. Failures, such as a session that isn't running,
+exit with other codes.`,
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Discover config file
+	configPath, err := config.Discover(cfgFile)
+	if err != nil {
+		logger.Error("No config file found")
+		logger.Info("Run 'hive generate' to create a new config file")
+		return err
+	}
+
+	// Parse config
+	cfg, err := config.Parse(configPath)
+	if err != nil {
+		logger.Error("Failed to parse config")
+		return err
+	}
+
+	client := tmux.NewSessionClient(cfg.Session)
+
+	if !client.SessionExists(ctx, cfg.Session.Name) {
+		logger.Errorf("Session '%s' is not running", cfg.Session.Name)
+		return fmt.Errorf("session '%s' is not running: %w", cfg.Session.Name, tmux.ErrSessionNotFound)
+	}
+
+	live, err := client.ExportSession(ctx, cfg.Session.Name, tmux.ExportOptions{KeepDefaults: true, KeepEnv: true})
+	if err != nil {
+		logger.Error("Failed to read session state")
+		logHint(client, err)
+		return err
+	}
+
+	changes := config.Diff(cfg, live)
+	if len(changes) == 0 {
+		logger.Infof("✓ Session '%s' matches %s", cfg.Session.Name, configPath)
+		return nil
+	}
+
+	fmt.Println(diffHeaderStyle.Render(fmt.Sprintf("--- %s", configPath)))
+	fmt.Println(diffHeaderStyle.Render(fmt.Sprintf("+++ session %s", cfg.Session.Name)))
+	printChanges(changes)
+
+	// Drift is reported through the exit status, not as a usage error
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return fmt.Errorf("%w: %d change(s) in session '%s'", errDrift, len(changes), cfg.Session.Name)
+}
+
+// printChanges renders a list of config changes, one per line
+func printChanges(changes []config.Change) {
+	for _, change := range changes {
+		switch change.Kind {
+		case config.ChangeMissing:
+			fmt.Println(diffMissingStyle.Render(fmt.Sprintf("- %s: %s", change.Path, change.Want)))
+		case config.ChangeExtra:
+			fmt.Println(diffExtraStyle.Render(fmt.Sprintf("+ %s: %s", change.Path, change.Got)))
+		default:
+			fmt.Println(diffModifiedStyle.Render(fmt.Sprintf("~ %s: %s → %s", change.Path, change.Want, change.Got)))
+		}
+	}
+}
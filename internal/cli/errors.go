@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/arch-err/tmux-hive/internal/tmux"
+)
+
+// Exit codes for drift and tmux failures, so scripts can tell them apart
+const (
+	exitError           = 1
+	exitDrift           = 2
+	exitNoServer        = 3
+	exitSessionNotFound = 4
+	exitTargetNotFound  = 5
+	exitInvalidOption   = 6
+	exitPaneTooSmall    = 7
+	exitSessionExists   = 8
+)
+
+// errDrift is returned when a session differs from its config
+var errDrift = errors.New("session differs from config")
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errDrift):
+		return exitDrift
+	case errors.Is(err, tmux.ErrNoServer):
+		return exitNoServer
+	case errors.Is(err, tmux.ErrSessionNotFound):
+		return exitSessionNotFound
+	case errors.Is(err, tmux.ErrTargetNotFound):
+		return exitTargetNotFound
+	case errors.Is(err, tmux.ErrInvalidOption):
+		return exitInvalidOption
+	case errors.Is(err, tmux.ErrPaneTooSmall):
+		return exitPaneTooSmall
+	case errors.Is(err, tmux.ErrSessionExists):
+		return exitSessionExists
+	default:
+		return exitError
+	}
+}
+
+// logHint suggests how to fix a classified tmux failure
+func logHint(client *tmux.Client, err error) {
+	switch {
+	case errors.Is(err, tmux.ErrNoServer):
+		logger.Infof("No tmux server is running; start one with 'hive launch' or '%s new-session'", tmuxInvocation(client))
+	case errors.Is(err, tmux.ErrSessionNotFound):
+		logger.Infof("List running sessions with: %s ls", tmuxInvocation(client))
+	case errors.Is(err, tmux.ErrTargetNotFound):
+		logger.Info("A window or pane disappeared while hive was working; compare the session with the config using 'hive diff'")
+	case errors.Is(err, tmux.ErrInvalidOption):
+		logger.Info("Check the option names and values in your config against 'man tmux'")
+	case errors.Is(err, tmux.ErrPaneTooSmall):
+		logger.Info("The window is too small for all of its panes; enlarge the terminal or use fewer panes per window")
+	}
+}
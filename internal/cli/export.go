@@ -1,55 +1,157 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/arch-err/tmux-hive/internal/config"
 	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
 
-var exportOutput string
+var (
+	exportOutput      string
+	exportSocketName  string
+	exportSocketPath  string
+	exportShells      []string
+	exportSession     string
+	exportAll         bool
+	exportEnvDeny     []string
+	exportEnvRedact   []string
+	exportInteractive bool
+)
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
-	Short: "Export current tmux session to a hive configuration",
+	Short: "Export tmux sessions to hive configurations",
 	Long: `Export the current tmux session to a hive configuration file.
 
 Captures the current session structure including:
 - Windows and their layouts
 - Panes and their working directories
-- Running commands in each pane
+- Running commands in each pane, with their arguments
 - Session options
 - Environment variables
 
-Must be run from within a tmux session.`,
+Must be run from within a tmux session. When hive runs inside a tmux server
+other than the default one, the exported config records its socket so that
+'hive launch' targets the same server; use -L or -S to pick one explicitly.
+
+Use --session to export another session, which also works from outside tmux.
+Use --all to export every session on the server: with -o pointing at a
+directory (existing, or ending in /) each session is written to its own
+NAME.yaml, otherwise all sessions go into one multi-document YAML file.
+
+The environment is filtered: variables inherited unchanged from the global
+environment and volatile ones such as DISPLAY or SSH_AUTH_SOCK are dropped,
+and values of variables named like *_TOKEN, *_KEY, *PASSWORD* or *SECRET* are
+replaced with <redacted>. Add patterns with --env-deny and --env-redact.
+
+Use -i to pick the windows to export and what to capture of each, and to
+change the session name and base directory before the config is written.
+
+Panes idle at a shell prompt are exported without a command. Common shells
+and $SHELL are recognized; add others with --shell.`,
 	RunE: runExport,
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
 	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "output file (default: stdout)")
+	exportCmd.Flags().StringVarP(&exportSocketName, "socket-name", "L", "", "tmux server socket name")
+	exportCmd.Flags().StringVarP(&exportSocketPath, "socket-path", "S", "", "tmux server socket path")
+	exportCmd.Flags().StringSliceVar(&exportShells, "shell", nil, "treat this program as a shell (repeatable)")
+	exportCmd.Flags().StringVarP(&exportSession, "session", "s", "", "export this session instead of the current one")
+	exportCmd.Flags().BoolVarP(&exportAll, "all", "a", false, "export every session on the server")
+	exportCmd.Flags().StringSliceVar(&exportEnvDeny, "env-deny", nil, "leave out environment variables matching this pattern (repeatable)")
+	exportCmd.Flags().StringSliceVar(&exportEnvRedact, "env-redact", nil, "redact environment variables matching this pattern (repeatable)")
+	exportCmd.Flags().BoolVarP(&exportInteractive, "interactive", "i", false, "pick the windows to export and edit the session name and base_dir")
+	exportCmd.MarkFlagsMutuallyExclusive("socket-name", "socket-path")
+	exportCmd.MarkFlagsMutuallyExclusive("session", "all")
+	exportCmd.MarkFlagsMutuallyExclusive("interactive", "all")
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
-	// Check if we're in a tmux session
-	sessionName, err := tmux.GetCurrentSession()
-	if err != nil {
-		logger.Error("Not in a tmux session")
-		logger.Info("Run this command from within a tmux session")
-		return err
+	ctx := cmd.Context()
+	client := tmux.NewClient()
+
+	// Default to the server hive is running inside
+	client.SocketName, client.SocketPath = exportSocketName, exportSocketPath
+	if !client.CustomServer() {
+		client.SocketName, client.SocketPath = tmux.CurrentServer()
+	}
+
+	opts := tmux.ExportOptions{
+		Shells:    exportShells,
+		EnvDeny:   exportEnvDeny,
+		EnvRedact: exportEnvRedact,
+	}
+	if exportAll {
+		return exportAllSessions(ctx, client, opts)
+	}
+
+	sessionName := exportSession
+	if sessionName == "" {
+		// Check if we're in a tmux session
+		current, err := client.GetCurrentSession(ctx)
+		if err != nil {
+			logger.Error("Not in a tmux session")
+			logger.Info("Run this command from within a tmux session, or pick one with --session")
+			logHint(client, err)
+			return err
+		}
+		sessionName = current
+	} else if !client.SessionExists(ctx, "="+sessionName) {
+		logger.Errorf("Session '%s' not found", sessionName)
+		return fmt.Errorf("session '%s' not found: %w", sessionName, tmux.ErrSessionNotFound)
+	}
+
+	if exportInteractive {
+		windows, err := selectWindows(ctx, client, sessionName)
+		if err != nil {
+			return err
+		}
+		opts.Windows = windows
 	}
 
 	logger.Infof("Exporting session '%s'", sessionName)
 
 	// Export the session
-	cfg, err := tmux.Export()
+	cfg, err := client.ExportSession(ctx, sessionName, opts)
 	if err != nil {
 		logger.Error("Failed to export session")
+		logHint(client, err)
 		return err
 	}
 
+	if exportInteractive {
+		name, baseDir, err := askSession(cfg)
+		if err != nil {
+			return err
+		}
+
+		// Directories are written relative to the base directory, so a new
+		// one takes another pass
+		if baseDir != cfg.Session.BaseDir {
+			opts.BaseDir = baseDir
+			if opts.BaseDir == "" {
+				opts.BaseDir = "/"
+			}
+			if cfg, err = client.ExportSession(ctx, sessionName, opts); err != nil {
+				logger.Error("Failed to export session")
+				logHint(client, err)
+				return err
+			}
+		}
+		cfg.Session.Name = name
+	}
+
 	// Marshal to YAML
 	data, err := config.Marshal(cfg)
 	if err != nil {
@@ -57,16 +159,183 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Output to file or stdout
-	if exportOutput != "" {
-		if err := os.WriteFile(exportOutput, data, 0644); err != nil {
-			logger.Error("Failed to write output file")
+	return writeExport(data)
+}
+
+// selectWindows asks which windows of a session to export and whether to
+// capture the commands and directories of their panes
+func selectWindows(ctx context.Context, client *tmux.Client, sessionName string) (map[string]tmux.WindowCapture, error) {
+	windows, err := client.ListWindows(ctx, sessionName)
+	if err != nil {
+		logger.Error("Failed to list windows")
+		logHint(client, err)
+		return nil, err
+	}
+
+	windowOptions := make([]huh.Option[string], len(windows))
+	for i, window := range windows {
+		label := fmt.Sprintf("%s: %s", window.Index, window.Name)
+		windowOptions[i] = huh.NewOption(label, window.Index).Selected(true)
+	}
+
+	var selected, captured []string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Select the windows to export").
+				Options(windowOptions...).
+				Value(&selected),
+		),
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Capture in each window").
+				Description("Panes without a command open a shell; panes without a directory start in the window's.").
+				OptionsFunc(func() []huh.Option[string] {
+					var options []huh.Option[string]
+					for _, window := range windows {
+						if !slices.Contains(selected, window.Index) {
+							continue
+						}
+						options = append(options,
+							huh.NewOption(fmt.Sprintf("%s: commands", window.Name), window.Index+"/commands").Selected(true),
+							huh.NewOption(fmt.Sprintf("%s: directories", window.Name), window.Index+"/dirs").Selected(true),
+						)
+					}
+					return options
+				}, &selected).
+				Value(&captured),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("window selection cancelled")
+	}
+	if len(selected) == 0 {
+		logger.Error("No windows selected")
+		return nil, fmt.Errorf("no windows selected")
+	}
+
+	captures := make(map[string]tmux.WindowCapture, len(selected))
+	for _, index := range selected {
+		captures[index] = tmux.WindowCapture{
+			Commands: slices.Contains(captured, index+"/commands"),
+			Dirs:     slices.Contains(captured, index+"/dirs"),
+		}
+	}
+	return captures, nil
+}
+
+// askSession asks for the session name and base directory of an exported
+// config, offering the exported ones
+func askSession(cfg *config.Config) (name, baseDir string, err error) {
+	name, baseDir = cfg.Session.Name, cfg.Session.BaseDir
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Session name").
+				Value(&name).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("session name is required")
+					}
+					return nil
+				}),
+			huh.NewInput().
+				Title("Base directory").
+				Description("Directories are written relative to it. Leave empty to keep them absolute.").
+				Value(&baseDir),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return "", "", fmt.Errorf("export cancelled")
+	}
+	return strings.TrimSpace(name), strings.TrimSpace(baseDir), nil
+}
+
+// exportAllSessions exports every session on the server, to one file per
+// session when the output is a directory and to a single stream otherwise
+func exportAllSessions(ctx context.Context, client *tmux.Client, opts tmux.ExportOptions) error {
+	sessions, err := client.ListSessions(ctx)
+	if err != nil {
+		logger.Error("Failed to list sessions")
+		logHint(client, err)
+		return err
+	}
+	if len(sessions) == 0 {
+		logger.Warn("No tmux sessions to export")
+		return nil
+	}
+
+	var configs []*config.Config
+	for _, name := range sessions {
+		logger.Infof("Exporting session '%s'", name)
+		cfg, err := client.ExportSession(ctx, name, opts)
+		if errors.Is(err, tmux.ErrSessionNotFound) {
+			// Closed since the sessions were listed
+			logger.Warnf("Session '%s' is gone, skipping it", name)
+			continue
+		}
+		if err != nil {
+			logger.Errorf("Failed to export session '%s'", name)
+			logHint(client, err)
+			return err
+		}
+		configs = append(configs, cfg)
+	}
+
+	if exportOutput != "" && isDirOutput(exportOutput) {
+		if err := os.MkdirAll(exportOutput, 0755); err != nil {
+			logger.Error("Failed to create output directory")
 			return err
 		}
-		logger.Infof("✓ Exported config written to %s", exportOutput)
-	} else {
+
+		for _, cfg := range configs {
+			path := filepath.Join(exportOutput, sessionFileName(cfg.Session.Name))
+			if err := config.Write(cfg, path); err != nil {
+				logger.Errorf("Failed to write config for session '%s'", cfg.Session.Name)
+				return err
+			}
+			logger.Infof("✓ Exported '%s' to %s", cfg.Session.Name, path)
+		}
+		return nil
+	}
+
+	data, err := config.MarshalAll(configs)
+	if err != nil {
+		logger.Error("Failed to marshal config")
+		return err
+	}
+
+	return writeExport(data)
+}
+
+// writeExport writes exported YAML to the output file or stdout
+func writeExport(data []byte) error {
+	if exportOutput == "" {
 		fmt.Print(string(data))
+		return nil
 	}
 
+	if err := os.WriteFile(exportOutput, data, 0644); err != nil {
+		logger.Error("Failed to write output file")
+		return err
+	}
+	logger.Infof("✓ Exported config written to %s", exportOutput)
 	return nil
 }
+
+// isDirOutput reports whether the output path names a directory
+func isDirOutput(path string) bool {
+	if strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// sessionFileName returns the config file name for a session
+// Session names may contain characters that can't appear in file names
+func sessionFileName(name string) string {
+	return strings.ReplaceAll(name, string(filepath.Separator), "_") + ".yaml"
+}
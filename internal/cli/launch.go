@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
-	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/arch-err/tmux-hive/internal/config"
 	"github.com/arch-err/tmux-hive/internal/tmux"
@@ -10,25 +16,44 @@ import (
 )
 
 var launchCmd = &cobra.Command{
-	Use:   "launch",
+	Use:   "launch [project]",
 	Short: "Launch a tmux session from a hive configuration",
 	Long: `Launch a tmux session from a hive configuration file.
 
 Creates a new tmux session with windows and panes as defined in the config.
-If the session already exists, an error will be returned.`,
+If the session already exists, hive exits with status 8.
+
+Without a project name, the config is .hive.yaml or hive.yaml in the current
+directory, or the one given with -c. With a name, hive launches that project
+from anywhere: a config registered with 'hive project add', or a named config
+in ~/.config/hive.
+
+Launch is all or nothing: if any step fails or launch is interrupted with
+Ctrl-C, the partially built session is removed again.
+
+server_options in the config are set on the tmux server, so they change
+every session running on it, not just the one launched.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runLaunch,
 }
 
+var (
+	keepPartial bool
+	dryRun      bool
+)
+
 func init() {
 	rootCmd.AddCommand(launchCmd)
+	launchCmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "keep a partially built session when launch fails")
+	launchCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the tmux commands launch would run without running them")
 }
 
 func runLaunch(cmd *cobra.Command, args []string) error {
-	// Discover config file
-	configPath, err := config.Discover(cfgFile)
+	ctx := cmd.Context()
+
+	// Discover config file, or that of the named project
+	configPath, err := discoverProjectConfig(args)
 	if err != nil {
-		logger.Error("No config file found")
-		logger.Info("Run 'hive generate' to create a new config file")
 		return err
 	}
 
@@ -47,47 +72,103 @@ func runLaunch(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	client := tmux.NewSessionClient(cfg.Session)
+	if dryRun {
+		return printLaunchPlan(ctx, client, cfg, configPath, nil)
+	}
+
+	if err := preflight(ctx, client, cfg); err != nil {
+		return err
+	}
+
 	// Check if session already exists
-	if tmux.SessionExists(cfg.Session.Name) {
+	if client.SessionExists(ctx, cfg.Session.Name) {
 		logger.Errorf("Session '%s' already exists", cfg.Session.Name)
-		logger.Info("Kill the session first with: tmux kill-session -t %s", cfg.Session.Name)
-		return err
+		logger.Infof("Kill the session first with: %s kill-session -t %s", tmuxInvocation(client), cfg.Session.Name)
+		return fmt.Errorf("%w: %s", tmux.ErrSessionExists, cfg.Session.Name)
 	}
 
 	logger.Infof("Launching session '%s'", cfg.Session.Name)
 
 	// Launch the session
-	if err := tmux.Launch(cfg); err != nil {
+	if err := launchSession(ctx, client, cfg, configPath); err != nil {
+		return err
+	}
+
+	attachSession(ctx, client, cfg.Session.Name)
+	return nil
+}
+
+// launchSession builds a session from the config at configPath, rolling it
+// back on failure or interrupt, and reports the outcome
+func launchSession(ctx context.Context, client *tmux.Client, cfg *config.Config, configPath string) error {
+	// Ctrl-C cancels the launch instead of killing hive mid-way
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	err := client.Launch(ctx, cfg, launchOptions(configPath))
+	if err != nil {
 		logger.Error("Failed to launch session")
+		logHint(client, err)
+
+		// Nothing was built when the session couldn't be created
+		var launchErr *tmux.LaunchError
+		if errors.As(err, &launchErr) && launchErr.Step > 0 {
+			if launchErr.RolledBack {
+				logger.Infof("Removed the partially built session '%s'", cfg.Session.Name)
+			} else {
+				logger.Warnf("Partially built session '%s' left in place", cfg.Session.Name)
+				logger.Info("Remove it with: hive clear")
+			}
+		}
 		return err
 	}
+	logger.Debugf("Session built in %s", time.Since(start).Round(time.Millisecond))
 
 	logger.Infof("✓ Session '%s' launched successfully", cfg.Session.Name)
+	return nil
+}
 
-	// Check if we're already in a tmux session
-	inTmux := os.Getenv("TMUX") != ""
-
-	var switchCmd *exec.Cmd
-	if inTmux {
-		// Switch to the new session instead of attaching
-		switchCmd = exec.Command("tmux", "switch-client", "-t", cfg.Session.Name)
-	} else {
-		// Attach to the session
-		switchCmd = exec.Command("tmux", "attach", "-t", cfg.Session.Name)
+// launchOptions returns the options that launch the config at configPath
+// and tag the session with it, for hive list
+func launchOptions(configPath string) tmux.LaunchOptions {
+	opts := tmux.LaunchOptions{KeepPartial: keepPartial, Version: Version}
+	if path, err := filepath.Abs(configPath); err == nil {
+		opts.ConfigPath = path
+	}
+	if data, err := os.ReadFile(configPath); err == nil {
+		opts.ConfigHash = config.Hash(data)
 	}
+	return opts
+}
 
-	switchCmd.Stdin = os.Stdin
-	switchCmd.Stdout = os.Stdout
-	switchCmd.Stderr = os.Stderr
+// retagSession records that a session matches the config at configPath
+// again, so that hive list stops reporting the config as changed
+func retagSession(ctx context.Context, client *tmux.Client, sessionName, configPath string) {
+	opts := launchOptions(configPath)
+	if err := client.TagConfig(ctx, sessionName, opts.ConfigPath, opts.ConfigHash); err != nil {
+		logger.Warnf("Failed to record the config in session '%s': %v", sessionName, err)
+	}
+}
 
-	if err := switchCmd.Run(); err != nil {
-		logger.Warnf("Failed to attach/switch to session (session created successfully): %v", err)
-		if inTmux {
-			logger.Infof("Switch manually with: tmux switch-client -t %s", cfg.Session.Name)
-		} else {
-			logger.Infof("Attach manually with: tmux attach -t %s", cfg.Session.Name)
-		}
+// printLaunchPlan prints the tmux commands that launch the config at
+// configPath, after the given steps, as shell commands
+func printLaunchPlan(ctx context.Context, client *tmux.Client, cfg *config.Config, configPath string, before []tmux.Step) error {
+	// The plan depends on the tmux version, but reviewing it shouldn't
+	// require tmux to be installed
+	version, err := client.Version(ctx)
+	if err != nil {
+		logger.Warnf("Could not detect the tmux version, planning for the latest release: %v", err)
+	} else if err := preflight(ctx, client, cfg); err != nil {
+		return err
 	}
 
+	steps := append(before, tmux.LaunchSteps(cfg, version, launchOptions(configPath), time.Now())...)
+	logger.Infof("Launch plan for session '%s' (%d commands)", cfg.Session.Name, len(steps))
+
+	for _, step := range steps {
+		fmt.Printf("# %s\n%s\n", step.Description, client.CommandLine(step))
+	}
 	return nil
 }
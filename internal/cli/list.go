@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+// Config states reported by hive list
+const (
+	listUpToDate = "up to date"
+	listChanged  = "changed"
+	listMissing  = "missing"
+	listUnknown  = "unknown"
+)
+
+var (
+	listSocketName string
+	listSocketPath string
+	listJSON       bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the running sessions hive launched",
+	Long: `List the sessions on the tmux server that were launched by hive.
+
+hive records the config file, its contents and the launch time in every
+session it launches. For each session the list shows its windows, panes and
+attached clients, the config it came from, and whether that config changed
+since launch:
+
+  up to date   the config is as it was at launch
+  changed      the config was edited; apply it with 'hive sync', or
+               write the session back into it with 'hive save'
+  missing      the config file is gone
+  unknown      the config couldn't be read at launch
+
+Lists the server hive runs in, or the default one; use -L or -S to pick
+another. Use --json for output to other programs.`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVarP(&listSocketName, "socket-name", "L", "", "tmux server socket name")
+	listCmd.Flags().StringVarP(&listSocketPath, "socket-path", "S", "", "tmux server socket path")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the sessions as JSON")
+	listCmd.MarkFlagsMutuallyExclusive("socket-name", "socket-path")
+}
+
+// listEntry is a session as printed by hive list --json
+type listEntry struct {
+	Session  string     `json:"session"`
+	Config   string     `json:"config"`
+	Status   string     `json:"status"`
+	Version  string     `json:"version,omitempty"`
+	Launched *time.Time `json:"launched,omitempty"`
+	Windows  int        `json:"windows"`
+	Panes    int        `json:"panes"`
+	Attached int        `json:"attached"`
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	client := tmux.NewClient()
+
+	// Default to the server hive is running inside
+	client.SocketName, client.SocketPath = listSocketName, listSocketPath
+	if !client.CustomServer() {
+		client.SocketName, client.SocketPath = tmux.CurrentServer()
+	}
+
+	sessions, err := client.ListManagedSessions(ctx)
+	if err != nil {
+		logger.Error("Failed to list sessions")
+		logHint(client, err)
+		return err
+	}
+
+	entries := make([]listEntry, len(sessions))
+	for i, session := range sessions {
+		entries[i] = listEntry{
+			Session:  session.Name,
+			Config:   session.ConfigPath,
+			Status:   configStatus(session),
+			Version:  session.Version,
+			Windows:  session.Windows,
+			Panes:    session.Panes,
+			Attached: session.Attached,
+		}
+		if !session.Launched.IsZero() {
+			launched := session.Launched
+			entries[i].Launched = &launched
+		}
+	}
+
+	if listJSON {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal sessions: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		logger.Info("No sessions launched by hive are running")
+		return nil
+	}
+
+	// The status goes last since its colors would throw off the alignment
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tWINDOWS\tPANES\tATTACHED\tLAUNCHED\tCONFIG\tSTATUS")
+	for _, entry := range entries {
+		launched := "-"
+		if entry.Launched != nil {
+			launched = entry.Launched.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			entry.Session, entry.Windows, entry.Panes, entry.Attached, launched,
+			config.CompressHome(entry.Config), renderStatus(entry.Status))
+	}
+	return w.Flush()
+}
+
+// configStatus compares the config file of a session with the one it was
+// launched from
+func configStatus(session tmux.ManagedSession) string {
+	data, err := os.ReadFile(session.ConfigPath)
+	switch {
+	case err != nil:
+		return listMissing
+	case session.ConfigHash == "":
+		return listUnknown
+	case config.Hash(data) != session.ConfigHash:
+		return listChanged
+	default:
+		return listUpToDate
+	}
+}
+
+// renderStatus colors a config status for the terminal
+func renderStatus(status string) string {
+	switch status {
+	case listChanged:
+		return diffModifiedStyle.Render(status)
+	case listMissing:
+		return diffMissingStyle.Render(status)
+	default:
+		return status
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+)
+
+// preflight checks that the installed tmux can run a configuration and
+// logs why it can't
+func preflight(ctx context.Context, client *tmux.Client, cfg *config.Config) error {
+	err := client.Preflight(ctx, cfg)
+	if err == nil {
+		return nil
+	}
+
+	var unsupported config.ValidationErrors
+	if errors.As(err, &unsupported) {
+		version, _ := client.Version(ctx)
+		logger.Errorf("Configuration is not supported by tmux %s", version)
+		logger.Info("Upgrade tmux or remove the listed settings")
+		return err
+	}
+
+	logger.Error("Failed to check the installed tmux")
+	logger.Info("Make sure tmux is installed and in your PATH")
+	return err
+}
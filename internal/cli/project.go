@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/project"
+	"github.com/spf13/cobra"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage the projects hive can launch by name",
+	Long: `Manage the projects that 'hive launch <name>' starts from anywhere.
+
+A project is either registered, mapping a name to a config file in its own
+directory, or a named config stored directly in the hive config directory
+as NAME.yaml. The registry and named configs live in $XDG_CONFIG_HOME/hive
+(~/.config/hive by default).`,
+}
+
+var projectAddCmd = &cobra.Command{
+	Use:   "add <name> [config]",
+	Short: "Register a config under a name",
+	Long: `Register a config file under a name, so that 'hive launch <name>' starts it
+from any directory.
+
+Without a config path, the config found in the current directory is used,
+or the one given with -c.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runProjectAdd,
+}
+
+var projectRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Unregister a project",
+	Long:  `Remove a project from the registry. Its config file is left in place.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProjectRm,
+}
+
+var projectLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List registered projects and named configs",
+	Args:  cobra.NoArgs,
+	RunE:  runProjectLs,
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectAddCmd, projectRmCmd, projectLsCmd)
+}
+
+func runProjectAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	configFile := cfgFile
+	if len(args) == 2 {
+		configFile = args[1]
+	}
+	configPath, err := config.DiscoverAbs(configFile)
+	if err != nil {
+		logger.Error("No config file found")
+		logger.Info("Pass the config to register, or run this in the directory holding it")
+		return err
+	}
+
+	if _, err := config.Parse(configPath); err != nil {
+		logger.Error("Failed to parse config")
+		return err
+	}
+
+	dir, err := project.Dir()
+	if err != nil {
+		logger.Error("Failed to find the hive config directory")
+		return err
+	}
+
+	registry, err := project.LoadRegistry(dir)
+	if err != nil {
+		logger.Error("Failed to load the project registry")
+		return err
+	}
+
+	if err := registry.Add(name, configPath); err != nil {
+		logger.Errorf("Failed to register project '%s'", name)
+		if _, ok := registry.Projects[name]; ok {
+			logger.Infof("Unregister it first with: hive project rm %s", name)
+		}
+		return err
+	}
+
+	if err := registry.Save(); err != nil {
+		logger.Error("Failed to save the project registry")
+		return err
+	}
+
+	logger.Infof("✓ Registered project '%s' for %s", name, config.CompressHome(configPath))
+	logger.Infof("Launch it from anywhere with: hive launch %s", name)
+	return nil
+}
+
+func runProjectRm(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	dir, err := project.Dir()
+	if err != nil {
+		logger.Error("Failed to find the hive config directory")
+		return err
+	}
+
+	registry, err := project.LoadRegistry(dir)
+	if err != nil {
+		logger.Error("Failed to load the project registry")
+		return err
+	}
+
+	if !registry.Remove(name) {
+		logger.Errorf("Project '%s' is not registered", name)
+		if found, err := project.Find(dir, name); err == nil {
+			logger.Infof("It is a named config; delete %s to remove it", config.CompressHome(found.Path))
+		}
+		return fmt.Errorf("project '%s' is not registered", name)
+	}
+
+	if err := registry.Save(); err != nil {
+		logger.Error("Failed to save the project registry")
+		return err
+	}
+
+	logger.Infof("✓ Unregistered project '%s'", name)
+	return nil
+}
+
+func runProjectLs(cmd *cobra.Command, args []string) error {
+	dir, err := project.Dir()
+	if err != nil {
+		logger.Error("Failed to find the hive config directory")
+		return err
+	}
+
+	projects, err := project.List(dir)
+	if err != nil {
+		logger.Error("Failed to list projects")
+		return err
+	}
+	if len(projects) == 0 {
+		logger.Info("No projects yet; register one with 'hive project add <name>'")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tCONFIG")
+	for _, p := range projects {
+		kind := "named"
+		if p.Registered {
+			kind = "registered"
+		}
+		path := config.CompressHome(p.Path)
+		if _, err := os.Stat(p.Path); err != nil {
+			path += " " + diffMissingStyle.Render("(missing)")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, kind, path)
+	}
+	return w.Flush()
+}
+
+// discoverProjectConfig finds the config to launch: that of the project
+// named in args, or else the one given with -c or found in the current
+// directory
+func discoverProjectConfig(args []string) (string, error) {
+	if len(args) == 0 {
+		configPath, err := config.Discover(cfgFile)
+		if err != nil {
+			logger.Error("No config file found")
+			logger.Info("Run 'hive generate' to create a new config file, or launch a project by name")
+		}
+		return configPath, err
+	}
+
+	name := args[0]
+	if cfgFile != "" {
+		logger.Error("Give either a project name or --config, not both")
+		return "", fmt.Errorf("both project '%s' and config %s given", name, cfgFile)
+	}
+
+	dir, err := project.Dir()
+	if err != nil {
+		logger.Error("Failed to find the hive config directory")
+		return "", err
+	}
+
+	found, err := project.Find(dir, name)
+	if err != nil {
+		logger.Errorf("Unknown project '%s'", name)
+		if errors.Is(err, project.ErrNotFound) {
+			logger.Info("List projects with 'hive project ls', or register one with 'hive project add'")
+		}
+		return "", err
+	}
+
+	if _, err := os.Stat(found.Path); err != nil {
+		logger.Errorf("Config of project '%s' not found: %s", name, config.CompressHome(found.Path))
+		return "", fmt.Errorf("config file not found: %s", found.Path)
+	}
+
+	return found.Path, nil
+}
@@ -2,8 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
 
 	"github.com/arch-err/tmux-hive/internal/config"
 	"github.com/arch-err/tmux-hive/internal/tmux"
@@ -12,25 +10,29 @@ import (
 )
 
 var relaunchCmd = &cobra.Command{
-	Use:   "relaunch",
+	Use:   "relaunch [project]",
 	Short: "Kill and relaunch the tmux session",
 	Long: `Kill the existing tmux session (if it exists) and relaunch it from the config.
 
-Combines 'hive clear' and 'hive launch' into a single command.
+Combines 'hive clear' and 'hive launch' into a single command, and like
+'hive launch' takes a project name to relaunch from anywhere.
 Asks for confirmation before killing the existing session.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runRelaunch,
 }
 
 func init() {
 	rootCmd.AddCommand(relaunchCmd)
+	relaunchCmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "keep a partially built session when launch fails")
+	relaunchCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the tmux commands relaunch would run without running them")
 }
 
 func runRelaunch(cmd *cobra.Command, args []string) error {
-	// Discover config file
-	configPath, err := config.Discover(cfgFile)
+	ctx := cmd.Context()
+
+	// Discover config file, or that of the named project
+	configPath, err := discoverProjectConfig(args)
 	if err != nil {
-		logger.Error("No config file found")
-		logger.Info("Run 'hive generate' to create a new config file")
 		return err
 	}
 
@@ -49,8 +51,24 @@ func runRelaunch(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	client := tmux.NewSessionClient(cfg.Session)
+	if dryRun {
+		var kill []tmux.Step
+		if client.SessionExists(ctx, cfg.Session.Name) {
+			kill = append(kill, tmux.Step{
+				Description: fmt.Sprintf("kill existing session '%s'", cfg.Session.Name),
+				Args:        []string{"kill-session", "-t", cfg.Session.Name},
+			})
+		}
+		return printLaunchPlan(ctx, client, cfg, configPath, kill)
+	}
+
+	if err := preflight(ctx, client, cfg); err != nil {
+		return err
+	}
+
 	// Check if session exists
-	if tmux.SessionExists(cfg.Session.Name) {
+	if client.SessionExists(ctx, cfg.Session.Name) {
 		// Ask for confirmation to kill
 		var confirm bool
 		form := huh.NewForm(
@@ -73,8 +91,9 @@ func runRelaunch(cmd *cobra.Command, args []string) error {
 
 		// Kill the session
 		logger.Infof("Killing session '%s'", cfg.Session.Name)
-		if err := tmux.KillSession(cfg.Session.Name); err != nil {
+		if err := client.KillSession(ctx, cfg.Session.Name); err != nil {
 			logger.Error("Failed to kill session")
+			logHint(client, err)
 			return err
 		}
 
@@ -84,37 +103,10 @@ func runRelaunch(cmd *cobra.Command, args []string) error {
 	// Launch the session (same as launch command)
 	logger.Infof("Launching session '%s'", cfg.Session.Name)
 
-	if err := tmux.Launch(cfg); err != nil {
-		logger.Error("Failed to launch session")
+	if err := launchSession(ctx, client, cfg, configPath); err != nil {
 		return err
 	}
 
-	logger.Infof("✓ Session '%s' launched successfully", cfg.Session.Name)
-
-	// Check if we're already in a tmux session
-	inTmux := os.Getenv("TMUX") != ""
-
-	var switchCmd *exec.Cmd
-	if inTmux {
-		// Switch to the new session instead of attaching
-		switchCmd = exec.Command("tmux", "switch-client", "-t", cfg.Session.Name)
-	} else {
-		// Attach to the session
-		switchCmd = exec.Command("tmux", "attach", "-t", cfg.Session.Name)
-	}
-
-	switchCmd.Stdin = os.Stdin
-	switchCmd.Stdout = os.Stdout
-	switchCmd.Stderr = os.Stderr
-
-	if err := switchCmd.Run(); err != nil {
-		logger.Warnf("Failed to attach/switch to session (session created successfully): %v", err)
-		if inTmux {
-			logger.Infof("Switch manually with: tmux switch-client -t %s", cfg.Session.Name)
-		} else {
-			logger.Infof("Attach manually with: tmux attach -t %s", cfg.Session.Name)
-		}
-	}
-
+	attachSession(ctx, client, cfg.Session.Name)
 	return nil
 }
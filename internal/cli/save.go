@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+// saveContext is the number of unchanged lines shown around each change
+const saveContext = 2
+
+var saveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save the running session's layout back into the config",
+	Long: `Update the hive configuration file with the current layout of its session.
+
+Windows are matched by name and panes by position. Window layouts and the
+panes of each window, with their splits, sizes and working directories, are
+taken from the session; windows and panes it no longer has are removed.
+
+Everything else is kept as written: comments, key order, options, environment
+variables, and the commands of existing panes, which the session only shows
+while they run. Panes without a command get the one running in them.
+
+The changes are shown as a diff and written only after confirmation.`,
+	RunE: runSave,
+}
+
+func init() {
+	rootCmd.AddCommand(saveCmd)
+}
+
+func runSave(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Discover config file
+	configPath, err := config.Discover(cfgFile)
+	if err != nil {
+		logger.Error("No config file found")
+		logger.Info("Run 'hive export -o .hive.yaml' to create one from the session")
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		logger.Error("Failed to read config")
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, err := config.ParseBytes(data)
+	if err != nil {
+		logger.Error("Failed to parse config")
+		return err
+	}
+
+	client := tmux.NewSessionClient(cfg.Session)
+
+	if !client.SessionExists(ctx, cfg.Session.Name) {
+		logger.Errorf("Session '%s' is not running", cfg.Session.Name)
+		return fmt.Errorf("session '%s' is not running", cfg.Session.Name)
+	}
+
+	live, err := client.ExportSession(ctx, cfg.Session.Name, tmux.ExportOptions{})
+	if err != nil {
+		logger.Error("Failed to read session state")
+		logHint(client, err)
+		return err
+	}
+
+	merged, err := config.Merge(data, configPath, live)
+	if err != nil {
+		logger.Error("Failed to merge the session into the config")
+		return err
+	}
+
+	// The merged config must still launch
+	mergedCfg, err := config.ParseBytes(merged)
+	if err == nil {
+		err = config.Validate(mergedCfg)
+	}
+	if err != nil {
+		logger.Error("Saving the session would produce an invalid config")
+		return err
+	}
+
+	lines := diffLines(string(data), string(merged))
+	if !hasChanges(lines) {
+		retagSession(ctx, client, cfg.Session.Name, configPath)
+		logger.Infof("✓ %s is up to date with session '%s'", configPath, cfg.Session.Name)
+		return nil
+	}
+
+	fmt.Println(diffHeaderStyle.Render(fmt.Sprintf("--- %s", configPath)))
+	fmt.Println(diffHeaderStyle.Render(fmt.Sprintf("+++ session %s", cfg.Session.Name)))
+	printDiffLines(lines)
+
+	var confirm bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Write these changes to %s?", configPath)).
+				Value(&confirm),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return fmt.Errorf("confirmation cancelled")
+	}
+
+	if !confirm {
+		logger.Info("Cancelled")
+		return nil
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(configPath, merged, mode); err != nil {
+		logger.Error("Failed to write config")
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	retagSession(ctx, client, cfg.Session.Name, configPath)
+	logger.Infof("✓ Session '%s' saved to %s", cfg.Session.Name, configPath)
+	return nil
+}
+
+// diffLine is a line of a diff: kept (' '), removed ('-') or added ('+')
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines compares two texts line by line, ignoring blank lines, and
+// returns the lines of both in order with what happened to each
+func diffLines(before, after string) []diffLine {
+	a, b := nonBlankLines(before), nonBlankLines(after)
+
+	// Longest common subsequence lengths of every pair of suffixes
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// nonBlankLines splits a text into its lines that aren't blank
+func nonBlankLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// hasChanges reports whether a diff adds or removes anything
+func hasChanges(lines []diffLine) bool {
+	for _, line := range lines {
+		if line.op != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// printDiffLines renders the changed lines of a diff with a few lines of
+// context around them
+func printDiffLines(lines []diffLine) {
+	show := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for j := max(0, i-saveContext); j <= min(len(lines)-1, i+saveContext); j++ {
+			show[j] = true
+		}
+	}
+
+	for i, line := range lines {
+		if !show[i] {
+			continue
+		}
+		if i > 0 && !show[i-1] {
+			fmt.Println(diffHeaderStyle.Render("..."))
+		}
+		switch line.op {
+		case '-':
+			fmt.Println(diffMissingStyle.Render("- " + line.text))
+		case '+':
+			fmt.Println(diffExtraStyle.Render("+ " + line.text))
+		default:
+			fmt.Println("  " + line.text)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scriptOutput      string
+	scriptTmuxVersion string
+)
+
+var scriptCmd = &cobra.Command{
+	Use:   "script",
+	Short: "Turn a hive configuration into a standalone shell script",
+	Long: `Turn a hive configuration into a standalone POSIX shell script.
+
+The script only needs tmux. It builds the same session, windows, panes,
+layouts, options and environment as 'hive launch', and attaches to the
+session instead if it is already running.
+
+The commands are planned for the installed tmux; use --tmux-version when the
+script will run on machines with an older one.`,
+	RunE: runScript,
+}
+
+func init() {
+	rootCmd.AddCommand(scriptCmd)
+	scriptCmd.Flags().StringVarP(&scriptOutput, "output", "o", "", "output file (default: stdout)")
+	scriptCmd.Flags().StringVar(&scriptTmuxVersion, "tmux-version", "", "tmux version to plan for (default: installed tmux)")
+}
+
+func runScript(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Discover config file
+	configPath, err := config.Discover(cfgFile)
+	if err != nil {
+		logger.Error("No config file found")
+		logger.Info("Run 'hive generate' to create a new config file")
+		return err
+	}
+
+	// Parse config
+	cfg, err := config.Parse(configPath)
+	if err != nil {
+		logger.Error("Failed to parse config")
+		return err
+	}
+
+	// Validate config
+	if err := config.Validate(cfg); err != nil {
+		logger.Error("Invalid configuration")
+		return err
+	}
+
+	client := tmux.NewSessionClient(cfg.Session)
+
+	var version tmux.Version
+	if scriptTmuxVersion != "" {
+		version, err = tmux.ParseVersion(scriptTmuxVersion)
+		if err != nil {
+			logger.Error("Invalid tmux version")
+			return err
+		}
+	} else if version, err = client.Version(ctx); err != nil {
+		logger.Warnf("Could not detect the tmux version, planning for the latest release: %v", err)
+	}
+
+	if unsupported := tmux.CheckSupport(cfg, version); len(unsupported) > 0 {
+		logger.Errorf("Configuration is not supported by tmux %s", version)
+		return unsupported
+	}
+
+	script := client.Script(cfg, version)
+
+	// Output to file or stdout
+	if scriptOutput != "" {
+		if err := os.WriteFile(scriptOutput, []byte(script), 0755); err != nil {
+			logger.Error("Failed to write output file")
+			return err
+		}
+		logger.Infof("✓ Script written to %s", scriptOutput)
+	} else {
+		fmt.Print(script)
+	}
+
+	return nil
+}
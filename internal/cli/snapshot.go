@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/snapshot"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotSocketName string
+	snapshotSocketPath string
+	snapshotInterval   time.Duration
+	snapshotKeep       int
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and restore every session, with scrollback",
+	Long: `Save every tmux session to a snapshot and rebuild them later, after a reboot.
+
+A snapshot records the exact layout of each window, the working directory and
+full command of each pane, options, the unfiltered environment, and the
+scrollback of every pane. Snapshots are kept in
+$XDG_STATE_HOME/hive/snapshots (~/.local/state/hive/snapshots by default) and
+are only readable by you.`,
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Snapshot every session on the server",
+	Long: `Snapshot every session on the tmux server hive runs in, or the one picked
+with -L or -S.
+
+With --interval, hive keeps running and takes a snapshot at every interval,
+skipping it while no sessions are running. Only the --keep most recent
+snapshots are kept.`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshotSave,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore [snapshot]",
+	Short: "Rebuild the sessions of a snapshot",
+	Long: `Rebuild the sessions of a snapshot, the most recent one by default.
+
+Each pane prints its saved scrollback, then starts a fresh shell and its
+command in its working directory. Sessions that are already running are
+left alone.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSnapshotRestore,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved snapshots",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotList,
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSaveCmd, snapshotRestoreCmd, snapshotListCmd)
+	snapshotSaveCmd.Flags().StringVarP(&snapshotSocketName, "socket-name", "L", "", "tmux server socket name")
+	snapshotSaveCmd.Flags().StringVarP(&snapshotSocketPath, "socket-path", "S", "", "tmux server socket path")
+	snapshotSaveCmd.Flags().DurationVar(&snapshotInterval, "interval", 0, "keep running and take a snapshot at this interval, e.g. 15m")
+	snapshotSaveCmd.Flags().IntVar(&snapshotKeep, "keep", 10, "number of snapshots to keep (0 keeps all)")
+	snapshotSaveCmd.MarkFlagsMutuallyExclusive("socket-name", "socket-path")
+}
+
+func runSnapshotSave(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	client := tmux.NewClient()
+
+	// Default to the server hive is running inside
+	client.SocketName, client.SocketPath = snapshotSocketName, snapshotSocketPath
+	if !client.CustomServer() {
+		client.SocketName, client.SocketPath = tmux.CurrentServer()
+	}
+
+	dir, err := snapshot.Dir()
+	if err != nil {
+		logger.Error("Failed to find the snapshot directory")
+		return err
+	}
+
+	// Ctrl-C stops hive between snapshots instead of killing it mid-way
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if snapshotInterval <= 0 {
+		return saveSnapshot(ctx, client, dir)
+	}
+
+	logger.Infof("Taking a snapshot every %s", snapshotInterval)
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+	for {
+		// A failed snapshot shouldn't stop the next ones
+		_ = saveSnapshot(ctx, client, dir)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// saveSnapshot snapshots every session on the server into dir and prunes
+// old snapshots
+func saveSnapshot(ctx context.Context, client *tmux.Client, dir string) error {
+	sessions, err := snapshot.Capture(ctx, client)
+	if err != nil {
+		logger.Error("Failed to snapshot sessions")
+		logHint(client, err)
+		return err
+	}
+	if len(sessions) == 0 {
+		logger.Warn("No tmux sessions to snapshot")
+		return nil
+	}
+
+	saved, err := snapshot.Write(dir, time.Now(), sessions)
+	if err != nil {
+		logger.Error("Failed to write snapshot")
+		return err
+	}
+
+	if _, err := snapshot.Prune(dir, snapshotKeep); err != nil {
+		logger.Warnf("Failed to remove old snapshots: %v", err)
+	}
+
+	logger.Infof("✓ Saved %d session(s) to %s", len(sessions), saved.Path)
+	return nil
+}
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	dir, err := snapshot.Dir()
+	if err != nil {
+		logger.Error("Failed to find the snapshot directory")
+		return err
+	}
+
+	var path string
+	if len(args) == 1 {
+		// Only names from the snapshot directory, not paths out of it
+		names, err := snapshot.List(dir)
+		if err != nil {
+			logger.Error("Failed to list snapshots")
+			return err
+		}
+		if !slices.Contains(names, args[0]) {
+			logger.Errorf("Unknown snapshot '%s'", args[0])
+			logger.Info("List saved snapshots with 'hive snapshot list'")
+			return fmt.Errorf("snapshot not found: %s", args[0])
+		}
+		path = filepath.Join(dir, args[0])
+	} else if path, err = snapshot.Latest(dir); err != nil {
+		if errors.Is(err, snapshot.ErrNoSnapshots) {
+			logger.Error("No snapshots to restore")
+			logger.Info("Take one with 'hive snapshot save'")
+		}
+		return err
+	}
+
+	snap, err := snapshot.Load(path)
+	if err != nil {
+		logger.Errorf("Failed to load snapshot %s", filepath.Base(path))
+		logger.Info("List saved snapshots with 'hive snapshot list'")
+		return err
+	}
+
+	// The snapshot file may have been edited; check every session before
+	// restoring any
+	for _, cfg := range snap.Sessions {
+		if err := config.Validate(cfg); err != nil {
+			logger.Errorf("Snapshot %s has an invalid config for session '%s'", filepath.Base(path), cfg.Session.Name)
+			return err
+		}
+	}
+
+	logger.Infof("Restoring snapshot from %s", snap.Created.Local().Format(time.DateTime))
+
+	restored := 0
+	for i, cfg := range snap.Sessions {
+		client := tmux.NewSessionClient(cfg.Session)
+
+		if client.SessionExists(ctx, "="+cfg.Session.Name) {
+			logger.Warnf("Session '%s' is already running, skipping it", cfg.Session.Name)
+			continue
+		}
+
+		logger.Infof("Restoring session '%s'", cfg.Session.Name)
+		err := client.RestoreSession(ctx, cfg, func(window, pane int) string {
+			return snap.ScrollbackFile(i, window, pane)
+		})
+		if err != nil {
+			logger.Errorf("Failed to restore session '%s'", cfg.Session.Name)
+			logHint(client, err)
+			return err
+		}
+		restored++
+	}
+
+	logger.Infof("✓ Restored %d session(s)", restored)
+	return nil
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	dir, err := snapshot.Dir()
+	if err != nil {
+		logger.Error("Failed to find the snapshot directory")
+		return err
+	}
+
+	names, err := snapshot.List(dir)
+	if err != nil {
+		logger.Error("Failed to list snapshots")
+		return err
+	}
+	if len(names) == 0 {
+		logger.Info("No snapshots yet; take one with 'hive snapshot save'")
+		return nil
+	}
+
+	// Most recent first, as restore picks it
+	for i := len(names) - 1; i >= 0; i-- {
+		snap, err := snapshot.Load(filepath.Join(dir, names[i]))
+		if err != nil {
+			fmt.Printf("%s  (unreadable: %v)\n", names[i], err)
+			continue
+		}
+
+		sessions := make([]string, len(snap.Sessions))
+		for j, cfg := range snap.Sessions {
+			sessions[j] = cfg.Session.Name
+		}
+		fmt.Printf("%s  %d session(s): %s\n", names[i], len(sessions), strings.Join(sessions, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Reconcile the running session with the config",
+	Long: `Apply changes from the hive configuration to the running tmux session.
+
+Windows are matched by name and panes by position. Missing windows and panes
+are created, renamed windows and changed layouts, options and environment
+variables are updated. Windows and panes that did not change keep running
+untouched.
+
+Asks for confirmation before killing any window or pane, unless --yes is
+given.`,
+	RunE: runSync,
+}
+
+var syncYes bool
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().BoolVarP(&syncYes, "yes", "y", false, "kill windows and panes no longer in the config without asking")
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Discover config file
+	configPath, err := config.Discover(cfgFile)
+	if err != nil {
+		logger.Error("No config file found")
+		logger.Info("Run 'hive generate' to create a new config file")
+		return err
+	}
+
+	logger.Infof("Loading config from %s", configPath)
+
+	// Parse config
+	cfg, err := config.Parse(configPath)
+	if err != nil {
+		logger.Error("Failed to parse config")
+		return err
+	}
+
+	// Validate config
+	if err := config.Validate(cfg); err != nil {
+		logger.Error("Invalid configuration")
+		return err
+	}
+
+	client := tmux.NewSessionClient(cfg.Session)
+	if err := preflight(ctx, client, cfg); err != nil {
+		return err
+	}
+
+	if !client.SessionExists(ctx, cfg.Session.Name) {
+		logger.Errorf("Session '%s' is not running", cfg.Session.Name)
+		logger.Info("Start it with: hive launch")
+		return fmt.Errorf("session '%s' is not running", cfg.Session.Name)
+	}
+
+	plan, err := client.PlanSync(ctx, cfg)
+	if err != nil {
+		logger.Error("Failed to compare session with config")
+		logHint(client, err)
+		return err
+	}
+
+	if plan.Empty() {
+		retagSession(ctx, client, cfg.Session.Name, configPath)
+		logger.Infof("✓ Session '%s' is already in sync", cfg.Session.Name)
+		return nil
+	}
+
+	for _, action := range plan.Actions {
+		switch action.Kind {
+		case tmux.SyncAdd:
+			logger.Infof("+ %s", action.Description)
+		case tmux.SyncRemove:
+			logger.Warnf("- %s", action.Description)
+		default:
+			logger.Infof("~ %s", action.Description)
+		}
+	}
+
+	// Ask before killing anything
+	includeDestructive := syncYes
+	if removals := plan.Destructive(); len(removals) > 0 && !syncYes {
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Kill %d window(s)/pane(s) no longer in the config?", len(removals))).
+					Description("This will terminate the processes running in them. Choose No to apply only the other changes.").
+					Value(&includeDestructive),
+			),
+		)
+
+		if err := form.Run(); err != nil {
+			return fmt.Errorf("confirmation cancelled")
+		}
+	}
+
+	if err := plan.Apply(ctx, includeDestructive); err != nil {
+		logger.Error("Failed to sync session")
+		logHint(client, err)
+		return err
+	}
+
+	// Kept windows and panes still differ from the config
+	if includeDestructive || len(plan.Destructive()) == 0 {
+		retagSession(ctx, client, cfg.Session.Name, configPath)
+	}
+
+	logger.Infof("✓ Session '%s' synced", cfg.Session.Name)
+	return nil
+}
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
 	"github.com/spf13/cobra"
 )
 
@@ -48,6 +50,20 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Check the config against the installed tmux, if there is one
+	client := tmux.NewSessionClient(cfg.Session)
+	if err := client.Preflight(cmd.Context(), cfg); err != nil {
+		var unsupported config.ValidationErrors
+		if !errors.As(err, &unsupported) {
+			logger.Warnf("Skipped tmux compatibility check: %v", err)
+		} else {
+			version, _ := client.Version(cmd.Context())
+			logger.Errorf("Configuration is not supported by tmux %s", version)
+			fmt.Println(err.Error())
+			return err
+		}
+	}
+
 	logger.Info("✓ Configuration is valid")
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/arch-err/tmux-hive/internal/tmux"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +25,12 @@ var versionCmd = &cobra.Command{
 		fmt.Printf("Built: %s\n", BuildDate)
 		fmt.Printf("Go: %s\n", runtime.Version())
 		fmt.Printf("OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+
+		if version, err := tmux.NewClient().Version(cmd.Context()); err == nil {
+			fmt.Printf("tmux: %s\n", version)
+		} else {
+			fmt.Println("tmux: not found")
+		}
 	},
 }
 
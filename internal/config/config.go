@@ -1,25 +1,46 @@
 package config
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Config represents the complete hive configuration
 type Config struct {
 	Session SessionConfig          `yaml:"session"`
 	Windows []WindowConfig         `yaml:"windows"`
 	Options map[string]interface{} `yaml:"options,omitempty"`
-	Env     map[string]string      `yaml:"env,omitempty"`
+
+	// ServerOptions are set with set-option -s and apply to every session
+	// on the server
+	ServerOptions map[string]interface{} `yaml:"server_options,omitempty"`
+
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// Path is the file the config was read from; relative directories in
+	// it start from the directory holding it
+	Path string `yaml:"-"`
 }
 
 // SessionConfig represents session-level configuration
 type SessionConfig struct {
 	Name    string `yaml:"name"`
 	BaseDir string `yaml:"base_dir,omitempty"`
+
+	// Server selection, passed to tmux as -L, -S and -f
+	SocketName string `yaml:"socket_name,omitempty"`
+	SocketPath string `yaml:"socket_path,omitempty"`
+	TmuxConf   string `yaml:"tmux_conf,omitempty"`
 }
 
 // WindowConfig represents a tmux window configuration
 type WindowConfig struct {
-	Name   string       `yaml:"name"`
-	Dir    string       `yaml:"dir,omitempty"`
-	Layout string       `yaml:"layout,omitempty"`
-	Panes  []PaneConfig `yaml:"panes"`
+	Name    string                 `yaml:"name"`
+	Dir     string                 `yaml:"dir,omitempty"`
+	Layout  string                 `yaml:"layout,omitempty"`
+	Options map[string]interface{} `yaml:"options,omitempty"` // window options
+	Panes   []PaneConfig           `yaml:"panes"`
 }
 
 // PaneConfig represents a tmux pane configuration
@@ -28,6 +49,7 @@ type PaneConfig struct {
 	Cmd   string `yaml:"cmd,omitempty"`
 	Dir   string `yaml:"dir,omitempty"`
 	Split string `yaml:"split,omitempty"` // "horizontal" or "vertical"
+	Size  string `yaml:"size,omitempty"`  // "30%" of the split pane, or "20" lines/columns
 }
 
 // UnmarshalYAML implements custom unmarshaling for PaneConfig
@@ -51,7 +73,43 @@ func (p *PaneConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
-// ValidLayouts are the supported tmux layout strings
+// MarshalYAML implements custom marshaling for PaneConfig
+// Panes that only run a command are written in the short string form, and
+// the default vertical split is left out
+func (p PaneConfig) MarshalYAML() (interface{}, error) {
+	if p.Split == "vertical" {
+		p.Split = ""
+	}
+
+	// An empty pane is written as "", since yaml.v3 drops null entries of
+	// the panes list
+	if p.Dir == "" && p.Split == "" && p.Size == "" {
+		return p.Cmd, nil
+	}
+
+	type paneAlias PaneConfig
+	return paneAlias(p), nil
+}
+
+// FormatOptionValue converts an option value to the string tmux expects
+func FormatOptionValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "on"
+		}
+		return "off"
+	case int:
+		return fmt.Sprintf("%d", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ValidLayouts are the tmux preset layout names
+// A window layout may also be a tmux layout string, see package layout
 var ValidLayouts = []string{
 	"even-horizontal",
 	"even-vertical",
@@ -65,3 +123,17 @@ var ValidSplits = []string{
 	"horizontal",
 	"vertical",
 }
+
+// ParseSize parses a pane size, returning the number of cells or the
+// percentage of the pane being split
+func ParseSize(size string) (value int, percent bool, err error) {
+	digits, percent := strings.CutSuffix(size, "%")
+	value, err = strconv.Atoi(digits)
+	if err != nil || value <= 0 || strings.HasPrefix(digits, "+") {
+		return 0, false, fmt.Errorf("invalid size '%s', must be a number of cells or a percentage like 30%%", size)
+	}
+	if percent && value >= 100 {
+		return 0, false, fmt.Errorf("invalid size '%s', a percentage must be below 100%%", size)
+	}
+	return value, percent, nil
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes how a running session differs from its config
+type ChangeKind string
+
+const (
+	// ChangeMissing marks something defined in the config but absent from the session
+	ChangeMissing ChangeKind = "missing"
+	// ChangeExtra marks something present in the session but not in the config
+	ChangeExtra ChangeKind = "extra"
+	// ChangeModified marks a value that differs between config and session
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is a single difference between a config and a running session
+type Change struct {
+	Kind ChangeKind
+	Path string // e.g. windows[editor].panes[1].dir
+	Want string // value from the config
+	Got  string // value from the session
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeMissing:
+		return fmt.Sprintf("%s: missing (want %s)", c.Path, c.Want)
+	case ChangeExtra:
+		return fmt.Sprintf("%s: not in config (got %s)", c.Path, c.Got)
+	default:
+		return fmt.Sprintf("%s: want %s, got %s", c.Path, c.Want, c.Got)
+	}
+}
+
+// Diff compares a config (want) with a config exported from a running session
+// (got) and returns every difference, in config order.
+//
+// Windows are matched by name and panes by position. Pane directories are
+// compared after resolving them against the window and session directories.
+// Commands are compared by program name when got only knows the running
+// binary. Options and environment variables are owned by the config: only
+// keys defined in want are compared.
+func Diff(want, got *Config) []Change {
+	var changes []Change
+
+	if want.Session.Name != got.Session.Name {
+		changes = append(changes, Change{
+			Kind: ChangeModified,
+			Path: "session.name",
+			Want: want.Session.Name,
+			Got:  got.Session.Name,
+		})
+	}
+
+	used := make([]bool, len(got.Windows))
+	for _, window := range want.Windows {
+		path := fmt.Sprintf("windows[%s]", window.Name)
+
+		match := -1
+		for j, candidate := range got.Windows {
+			if !used[j] && candidate.Name == window.Name {
+				match = j
+				break
+			}
+		}
+
+		if match < 0 {
+			changes = append(changes, Change{
+				Kind: ChangeMissing,
+				Path: path,
+				Want: fmt.Sprintf("%d pane(s)", len(window.Panes)),
+			})
+			continue
+		}
+		used[match] = true
+
+		changes = append(changes, diffWindow(path, want, window, got, got.Windows[match])...)
+	}
+
+	for j, window := range got.Windows {
+		if used[j] {
+			continue
+		}
+		changes = append(changes, Change{
+			Kind: ChangeExtra,
+			Path: fmt.Sprintf("windows[%s]", window.Name),
+			Got:  fmt.Sprintf("%d pane(s)", len(window.Panes)),
+		})
+	}
+
+	changes = append(changes, diffOptions("options", want.Options, got.Options)...)
+	changes = append(changes, diffOptions("server_options", want.ServerOptions, got.ServerOptions)...)
+
+	for _, key := range sortedKeys(want.Env) {
+		gotValue, ok := got.Env[key]
+		path := fmt.Sprintf("env.%s", key)
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeMissing, Path: path, Want: want.Env[key]})
+		} else if gotValue != want.Env[key] {
+			changes = append(changes, Change{Kind: ChangeModified, Path: path, Want: want.Env[key], Got: gotValue})
+		}
+	}
+
+	return changes
+}
+
+// diffOptions compares the options defined in want with their values in got
+func diffOptions(path string, want, got map[string]interface{}) []Change {
+	var changes []Change
+
+	for _, key := range sortedKeys(want) {
+		wantValue := FormatOptionValue(want[key])
+		gotValue, ok := got[key]
+		keyPath := fmt.Sprintf("%s.%s", path, key)
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeMissing, Path: keyPath, Want: wantValue})
+		} else if FormatOptionValue(gotValue) != wantValue {
+			changes = append(changes, Change{Kind: ChangeModified, Path: keyPath, Want: wantValue, Got: FormatOptionValue(gotValue)})
+		}
+	}
+
+	return changes
+}
+
+// diffWindow compares the layout, options and panes of two matched windows
+func diffWindow(path string, wantCfg *Config, want WindowConfig, gotCfg *Config, got WindowConfig) []Change {
+	var changes []Change
+
+	if want.Layout != "" && want.Layout != got.Layout {
+		changes = append(changes, Change{
+			Kind: ChangeModified,
+			Path: path + ".layout",
+			Want: want.Layout,
+			Got:  orNone(got.Layout),
+		})
+	}
+
+	changes = append(changes, diffOptions(path+".options", want.Options, got.Options)...)
+
+	for i, pane := range want.Panes {
+		panePath := fmt.Sprintf("%s.panes[%d]", path, i)
+		if i >= len(got.Panes) {
+			changes = append(changes, Change{Kind: ChangeMissing, Path: panePath, Want: orShell(pane.Cmd)})
+			continue
+		}
+		gotPane := got.Panes[i]
+
+		if !sameCommand(pane.Cmd, gotPane.Cmd) {
+			changes = append(changes, Change{
+				Kind: ChangeModified,
+				Path: panePath + ".cmd",
+				Want: orShell(pane.Cmd),
+				Got:  orShell(gotPane.Cmd),
+			})
+		}
+
+		wantDir := absDir(wantCfg.PaneDir(want, pane))
+		gotDir := absDir(gotCfg.PaneDir(got, gotPane))
+		if wantDir != gotDir {
+			changes = append(changes, Change{
+				Kind: ChangeModified,
+				Path: panePath + ".dir",
+				Want: wantDir,
+				Got:  gotDir,
+			})
+		}
+	}
+
+	for i := len(want.Panes); i < len(got.Panes); i++ {
+		changes = append(changes, Change{
+			Kind: ChangeExtra,
+			Path: fmt.Sprintf("%s.panes[%d]", path, i),
+			Got:  orShell(got.Panes[i].Cmd),
+		})
+	}
+
+	return changes
+}
+
+// sameCommand reports whether a running command matches the configured one.
+// Programs match by name, wherever they were run from, and a bare program
+// name matches any configured command line starting with it.
+func sameCommand(want, got string) bool {
+	if want == got {
+		return true
+	}
+	wantFields, gotFields := strings.Fields(want), strings.Fields(got)
+	if len(wantFields) == 0 || len(gotFields) == 0 || filepath.Base(wantFields[0]) != filepath.Base(gotFields[0]) {
+		return false
+	}
+	return len(gotFields) == 1 || slices.Equal(wantFields[1:], gotFields[1:])
+}
+
+func absDir(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+func orShell(cmd string) string {
+	if cmd == "" {
+		return "(shell)"
+	}
+	return cmd
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// sortedKeys returns the keys of a map in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+package config
+
+import "testing"
+
+func diffTestConfig() *Config {
+	return &Config{
+		Session: SessionConfig{
+			Name:    "test",
+			BaseDir: "/srv/app",
+		},
+		Windows: []WindowConfig{
+			{
+				Name:   "editor",
+				Layout: "main-vertical",
+				Panes: []PaneConfig{
+					{Cmd: "nvim ."},
+					{Cmd: "", Split: "vertical"},
+				},
+			},
+			{
+				Name: "api",
+				Dir:  "api",
+				Panes: []PaneConfig{
+					{Cmd: "npm run dev"},
+				},
+			},
+		},
+		Options: map[string]interface{}{
+			"mouse":      true,
+			"base-index": 1,
+		},
+		Env: map[string]string{
+			"NODE_ENV": "development",
+		},
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	got := &Config{
+		Session: SessionConfig{Name: "test"},
+		Windows: []WindowConfig{
+			{
+				Name:   "editor",
+				Layout: "main-vertical",
+				Panes: []PaneConfig{
+					{Cmd: "nvim", Dir: "/srv/app"},
+					{Cmd: "", Dir: "/srv/app"},
+				},
+			},
+			{
+				Name: "api",
+				Panes: []PaneConfig{
+					{Cmd: "npm", Dir: "/srv/app/api"},
+				},
+			},
+		},
+		Options: map[string]interface{}{
+			"mouse":         "on",
+			"base-index":    "1",
+			"history-limit": "2000",
+		},
+		Env: map[string]string{
+			"NODE_ENV": "development",
+			"DISPLAY":  ":0",
+		},
+	}
+
+	if changes := Diff(diffTestConfig(), got); len(changes) != 0 {
+		t.Errorf("Diff() = %v, want no changes", changes)
+	}
+}
+
+func TestDiffChanges(t *testing.T) {
+	got := &Config{
+		Session: SessionConfig{Name: "test"},
+		Windows: []WindowConfig{
+			{
+				Name:    "editor",
+				Layout:  "tiled",
+				Options: map[string]interface{}{"mode-keys": "emacs"},
+				Panes: []PaneConfig{
+					{Cmd: "", Dir: "/srv/app"},
+					{Cmd: "", Dir: "/tmp"},
+					{Cmd: "htop", Dir: "/srv/app"},
+				},
+			},
+			{
+				Name: "scratch",
+				Panes: []PaneConfig{
+					{Dir: "/tmp"},
+				},
+			},
+		},
+		Options: map[string]interface{}{
+			"mouse": "off",
+		},
+		Env: map[string]string{
+			"NODE_ENV": "production",
+		},
+	}
+
+	want := map[string]ChangeKind{
+		"windows[editor].layout":            ChangeModified,
+		"windows[editor].panes[0].cmd":      ChangeModified,
+		"windows[editor].panes[1].dir":      ChangeModified,
+		"windows[editor].panes[2]":          ChangeExtra,
+		"windows[api]":                      ChangeMissing,
+		"windows[scratch]":                  ChangeExtra,
+		"options.mouse":                     ChangeModified,
+		"options.base-index":                ChangeMissing,
+		"server_options.escape-time":        ChangeMissing,
+		"windows[editor].options.mode-keys": ChangeModified,
+		"env.NODE_ENV":                      ChangeModified,
+	}
+
+	wantCfg := diffTestConfig()
+	wantCfg.ServerOptions = map[string]interface{}{"escape-time": 10}
+	wantCfg.Windows[0].Options = map[string]interface{}{"mode-keys": "vi"}
+
+	changes := Diff(wantCfg, got)
+	if len(changes) != len(want) {
+		t.Errorf("Diff() returned %d changes, want %d: %v", len(changes), len(want), changes)
+	}
+
+	for _, change := range changes {
+		kind, ok := want[change.Path]
+		if !ok {
+			t.Errorf("unexpected change %v", change)
+			continue
+		}
+		if change.Kind != kind {
+			t.Errorf("change %s: kind = %s, want %s", change.Path, change.Kind, kind)
+		}
+	}
+}
+
+func TestSameCommand(t *testing.T) {
+	tests := []struct {
+		want  string
+		got   string
+		match bool
+	}{
+		{"", "", true},
+		{"npm run dev", "npm run dev", true},
+		{"npm run dev", "npm", true},
+		{"/usr/bin/htop -d 5", "htop", true},
+		{"npm run dev", "", false},
+		{"", "htop", false},
+		{"npm run dev", "npm run build", false},
+		{"python3 -m http.server", "/opt/pyenv/bin/python3 -m http.server", true},
+		{"/usr/bin/python3 -m http.server", "python3 -m http.server", true},
+		{"python3 -m http.server", "/opt/pyenv/bin/python3 -m http.server 9000", false},
+		{"python3 -m http.server", "/opt/bin/python2 -m http.server", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameCommand(tt.want, tt.got); got != tt.match {
+			t.Errorf("sameCommand(%q, %q) = %v, want %v", tt.want, tt.got, got, tt.match)
+		}
+	}
+}
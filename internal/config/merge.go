@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge updates the YAML of a config file with the windows and panes of a
+// config exported from its running session. Only the structure is taken from
+// the session: window layouts, and the panes with their splits, sizes and
+// directories. Everything else is kept as written, including comments, key
+// order, options, the environment and the commands of existing panes, which
+// the session only shows while they run.
+//
+// Windows are matched by name and panes by position. Windows and panes the
+// session no longer has are dropped, new ones are added. Relative directories
+// in data start from the directory of path, the file it was read from.
+func Merge(data []byte, path string, live *Config) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to merge config: not a YAML mapping")
+	}
+	root := doc.Content[0]
+
+	cfg := Config{Path: path}
+	if err := doc.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	windows := mappingValue(root, "windows")
+	if windows == nil || windows.Kind != yaml.SequenceNode {
+		windows = &yaml.Node{Kind: yaml.SequenceNode}
+		setMappingValue(root, "windows", windows, "options")
+	}
+
+	// Decode the windows one by one to keep them aligned with their nodes
+	existing := make([]WindowConfig, len(windows.Content))
+	for i, node := range windows.Content {
+		if err := node.Decode(&existing[i]); err != nil {
+			return nil, fmt.Errorf("failed to parse windows[%d]: %w", i, err)
+		}
+	}
+
+	used := make([]bool, len(existing))
+	content := make([]*yaml.Node, 0, len(live.Windows))
+	for _, window := range live.Windows {
+		liveDir := ResolveDir(live.BaseDir(), window.Dir)
+
+		match := -1
+		for i, candidate := range existing {
+			if !used[i] && candidate.Name == window.Name {
+				match = i
+				break
+			}
+		}
+
+		if match < 0 {
+			window.Dir = relativeDir(cfg.BaseDir(), liveDir)
+			node, err := encodeNode(window)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, node)
+			continue
+		}
+		used[match] = true
+
+		node := windows.Content[match]
+		if err := mergeWindow(node, existing[match], ResolveDir(cfg.BaseDir(), existing[match].Dir), window, liveDir); err != nil {
+			return nil, err
+		}
+		content = append(content, node)
+	}
+	windows.Content = content
+	spaceSections(root)
+
+	return encodeYAML(&doc, detectIndent(data))
+}
+
+// detectIndent returns the indentation of a YAML document: that of its
+// first indented line, or 2
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent >= 2 && indent <= 8 {
+			return indent
+		}
+		break
+	}
+	return 2
+}
+
+// mergeWindow updates the node of a window with the layout and panes of its
+// live counterpart. Directories are compared after resolving them against
+// the window directory of each side.
+func mergeWindow(node *yaml.Node, window WindowConfig, windowDir string, live WindowConfig, liveDir string) error {
+	if window.Layout != live.Layout {
+		if live.Layout == "" {
+			deleteMappingValue(node, "layout")
+		} else {
+			setMappingValue(node, "layout", scalarNode(live.Layout), "options", "panes")
+		}
+	}
+
+	panes := mappingValue(node, "panes")
+	if panes == nil || panes.Kind != yaml.SequenceNode {
+		panes = &yaml.Node{Kind: yaml.SequenceNode}
+		setMappingValue(node, "panes", panes)
+	}
+
+	// yaml.v3 reads the comment of a trailing empty pane, as in "- # shell",
+	// as a foot comment of the panes key
+	if n := len(panes.Content); n > 0 && panes.Content[n-1].Tag == "!!null" {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Value == "panes" && panes.Content[n-1].LineComment == "" {
+				panes.Content[n-1].LineComment, key.FootComment = key.FootComment, ""
+			}
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(live.Panes))
+	for i, livePane := range live.Panes {
+		dir := ResolveDir(liveDir, livePane.Dir)
+
+		if i >= len(panes.Content) {
+			livePane.Dir = relativeDir(windowDir, dir)
+			paneNode, err := encodeNode(livePane)
+			if err != nil {
+				return err
+			}
+			content = append(content, paneNode)
+			continue
+		}
+
+		paneNode := panes.Content[i]
+		var pane PaneConfig
+		if err := paneNode.Decode(&pane); err != nil {
+			return fmt.Errorf("failed to parse windows[%s].panes[%d]: %w", window.Name, i, err)
+		}
+
+		merged := pane
+		if merged.Cmd == "" {
+			merged.Cmd = livePane.Cmd
+		}
+		if ResolveDir(windowDir, pane.Dir) != dir {
+			merged.Dir = relativeDir(windowDir, dir)
+		}
+		if i > 0 && splitOrDefault(pane.Split) != splitOrDefault(livePane.Split) {
+			merged.Split = livePane.Split
+		}
+		// Sizes don't matter when a layout is applied afterwards
+		if live.Layout == "" && pane.Size != livePane.Size {
+			merged.Size = livePane.Size
+		}
+
+		if merged != pane {
+			var err error
+			if paneNode, err = mergePane(paneNode, merged); err != nil {
+				return err
+			}
+		}
+		content = append(content, paneNode)
+	}
+	panes.Content = content
+
+	return nil
+}
+
+// mergePane writes the fields of a pane into its node. A pane written as a
+// plain string becomes a mapping when it needs more than a command.
+func mergePane(node *yaml.Node, pane PaneConfig) (*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		merged, err := encodeNode(pane)
+		if err != nil {
+			return nil, err
+		}
+		merged.HeadComment, merged.FootComment = node.HeadComment, node.FootComment
+
+		// A mapping's line comment goes after its first value
+		if merged.Kind == yaml.MappingNode {
+			merged.Content[1].LineComment = node.LineComment
+		} else {
+			merged.LineComment = node.LineComment
+		}
+		return merged, nil
+	}
+
+	fields := []struct{ key, value string }{
+		{"cmd", pane.Cmd},
+		{"dir", pane.Dir},
+		{"split", pane.Split},
+		{"size", pane.Size},
+	}
+	for _, field := range fields {
+		current := mappingValue(node, field.key)
+		switch {
+		case field.value == "":
+			deleteMappingValue(node, field.key)
+		case current == nil || current.Value != field.value:
+			setMappingValue(node, field.key, scalarNode(field.value))
+		}
+	}
+	return node, nil
+}
+
+// relativeDir returns a directory relative to base, "" if it is base, or
+// the absolute directory, with ~ for home, when it is outside base
+func relativeDir(base, dir string) string {
+	rel, err := filepath.Rel(base, dir)
+	switch {
+	case err != nil || rel == ".." || strings.HasPrefix(rel, "../"):
+		return CompressHome(dir)
+	case rel == ".":
+		return ""
+	default:
+		return rel
+	}
+}
+
+// splitOrDefault returns the split of a pane, which is vertical by default
+func splitOrDefault(split string) string {
+	if split == "" {
+		return "vertical"
+	}
+	return split
+}
+
+// encodeNode encodes a value into a YAML node
+func encodeNode(value interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return &node, nil
+}
+
+// scalarNode returns a node for a string, quoted when YAML would read it as
+// another type
+func scalarNode(value string) *yaml.Node {
+	node, _ := encodeNode(value)
+	return node
+}
+
+// mappingValue returns the value of a key in a mapping node, nil if absent
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value of a key in a mapping node, keeping its
+// comments. A new key is added before the first of the given keys present,
+// or else at the end.
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node, before ...string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			old := node.Content[i+1]
+			value.LineComment, value.FootComment = old.LineComment, old.FootComment
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	for _, next := range before {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == next {
+				node.Content = slices.Insert(node.Content, i, keyNode, value)
+				return
+			}
+		}
+	}
+	node.Content = append(node.Content, keyNode, value)
+}
+
+// deleteMappingValue removes a key from a mapping node
+func deleteMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = slices.Delete(node.Content, i, i+2)
+			return
+		}
+	}
+}
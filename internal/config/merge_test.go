@@ -0,0 +1,193 @@
+package config
+
+import (
+	"testing"
+)
+
+const mergeTestFile = `# Project session
+session:
+  name: proj
+  base_dir: /srv/proj
+
+# One window per service
+windows:
+  - name: editor # main window
+    layout: main-vertical
+    panes:
+      - nvim . # editor
+      - # shell
+  - name: api
+    dir: api
+    panes:
+      - cmd: go run . # restarted often
+      - cmd: go test ./...
+        split: horizontal
+  - name: scratch
+    panes:
+      - htop
+
+env:
+  API_TOKEN: from-vault # not exported
+options:
+  mouse: true
+`
+
+func TestMerge(t *testing.T) {
+	live := &Config{
+		Session: SessionConfig{Name: "proj", BaseDir: "/srv/proj"},
+		Windows: []WindowConfig{
+			{
+				Name:   "editor",
+				Layout: "main-horizontal",
+				Panes:  []PaneConfig{{Cmd: "nvim", Dir: "src"}, {Cmd: "", Dir: "docs"}, {Cmd: "lazygit"}},
+			},
+			{
+				Name: "api",
+				Dir:  "api",
+				Panes: []PaneConfig{
+					{Cmd: ""},
+					{Cmd: "go", Split: "vertical", Size: "30%"},
+				},
+			},
+			{
+				Name:  "logs",
+				Dir:   "/var/log",
+				Panes: []PaneConfig{{Cmd: "tail -f syslog"}},
+			},
+		},
+		Env: map[string]string{"API_TOKEN": "<redacted>"},
+	}
+
+	want := `# Project session
+session:
+  name: proj
+  base_dir: /srv/proj
+
+# One window per service
+windows:
+  - name: editor # main window
+    layout: main-horizontal
+    panes:
+      - cmd: nvim . # editor
+        dir: src
+      - dir: docs # shell
+      - lazygit
+
+  - name: api
+    dir: api
+    panes:
+      - cmd: go run . # restarted often
+      - cmd: go test ./...
+        split: vertical
+        size: 30%
+
+  - name: logs
+    dir: /var/log
+    panes:
+      - tail -f syslog
+
+env:
+  API_TOKEN: from-vault # not exported
+
+options:
+  mouse: true
+`
+
+	got, err := Merge([]byte(mergeTestFile), "", live)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Merge() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeUnchanged(t *testing.T) {
+	file := `session:
+    name: proj
+    base_dir: /srv/proj
+
+windows:
+    - name: main
+      panes:
+        - npm start # dev server
+        - cmd: npm test
+          split: vertical
+`
+	live := &Config{
+		Session: SessionConfig{Name: "proj", BaseDir: "/srv/proj"},
+		Windows: []WindowConfig{{
+			Name:  "main",
+			Panes: []PaneConfig{{Cmd: "node"}, {}},
+		}},
+	}
+
+	got, err := Merge([]byte(file), "", live)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if string(got) != file {
+		t.Errorf("Merge() =\n%s\nwant it unchanged", got)
+	}
+}
+
+func TestMergeSpacing(t *testing.T) {
+	// Windows are spaced at any indentation, and block scalars are left
+	// alone even where their lines look like windows
+	file := `session:
+    name: proj
+    base_dir: /srv/proj
+x-notes: |
+  - not a window
+
+  done
+windows:
+    - name: main
+      panes:
+        - cmd: |
+            echo start
+
+            echo done
+    # the logs
+    - name: logs
+      panes:
+        - tail -f log
+`
+	want := `session:
+    name: proj
+    base_dir: /srv/proj
+
+x-notes: |
+    - not a window
+
+    done
+
+windows:
+    - name: main
+      panes:
+        - cmd: |
+            echo start
+
+            echo done
+
+    # the logs
+    - name: logs
+      panes:
+        - tail -f log
+`
+	live := &Config{
+		Session: SessionConfig{Name: "proj", BaseDir: "/srv/proj"},
+		Windows: []WindowConfig{
+			{Name: "main", Panes: []PaneConfig{{Cmd: "sh"}}},
+			{Name: "logs", Panes: []PaneConfig{{Cmd: "tail"}}},
+		},
+	}
+
+	got, err := Merge([]byte(file), "", live)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Merge() =\n%s\nwant\n%s", got, want)
+	}
+}
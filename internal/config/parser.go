@@ -1,8 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,32 +21,149 @@ func Parse(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	cfg, err := ParseBytes(data)
+	if err != nil {
+		return nil, err
 	}
 
-	return &cfg, nil
+	if cfg.Path, err = filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return cfg, nil
 }
 
 // ParseBytes parses a YAML configuration from bytes
+// A stream of several documents, such as the combined output of
+// hive export --all, is an error rather than cut down to its first config
 func ParseBytes(data []byte) (*Config, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	// Empty documents, such as after a trailing ---, don't count
+	for {
+		var next yaml.Node
+		err := decoder.Decode(&next)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		if len(next.Content) > 0 && next.Content[0].Tag != "!!null" {
+			return nil, fmt.Errorf("failed to parse YAML: found several documents, but a config file holds one session")
+		}
+	}
+
 	return &cfg, nil
 }
 
-// Marshal converts a Config to YAML bytes
+// Hash returns a short digest identifying the contents of a config file
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Marshal converts a Config to YAML bytes, laid out like a hand-written
+// config: two space indentation, a header comment, a blank line between
+// sections and between windows, and panes in their short form
 func Marshal(cfg *Config) ([]byte, error) {
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
+	header := fmt.Sprintf("hive configuration for the %q session\nLaunch with: hive launch -c <file>", cfg.Session.Name)
+	return marshalDocument(cfg, header)
+}
+
+// marshalDocument lays out a Config like Marshal, under the given header
+func marshalDocument(cfg *Config, header string) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(compact(cfg)); err != nil {
 		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
+	spaceSections(&node)
+	doc := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: header,
+		Content:     []*yaml.Node{&node},
+	}
+
+	return encodeYAML(doc, 2)
+}
+
+// compact returns a copy of a Config without pane directories that repeat
+// the directory of their window
+func compact(cfg *Config) *Config {
+	out := *cfg
+	out.Windows = make([]WindowConfig, len(cfg.Windows))
+	baseDir := cfg.BaseDir()
+	for i, window := range cfg.Windows {
+		windowDir := ResolveDir(baseDir, window.Dir)
+		window.Panes = slices.Clone(window.Panes)
+		for j, pane := range window.Panes {
+			if pane.Dir != "" && ResolveDir(windowDir, pane.Dir) == windowDir {
+				window.Panes[j].Dir = ""
+			}
+		}
+		out.Windows[i] = window
+	}
+	return &out
+}
+
+// spaceSections puts a blank line before every top-level key of a config
+// mapping but the first and before every window but the first, above the
+// comments that go with them
+func spaceSections(root *yaml.Node) {
+	for i := 2; i < len(root.Content); i += 2 {
+		root.Content[i].HeadComment = "\n" + root.Content[i].HeadComment
+	}
+
+	windows := mappingValue(root, "windows")
+	if windows == nil || windows.Kind != yaml.SequenceNode {
+		return
+	}
+	for i := 1; i < len(windows.Content); i++ {
+		windows.Content[i].HeadComment = "\n" + windows.Content[i].HeadComment
+	}
+}
+
+// indentedBlankLine matches the indentation yaml.v3 writes on the blank
+// lines of head comments. Scalars never end a line in spaces, since yaml.v3
+// quotes them instead.
+var indentedBlankLine = regexp.MustCompile(`(?m)^ +$`)
+
+// encodeYAML encodes a YAML node with the given indentation
+func encodeYAML(node *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(node); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return indentedBlankLine.ReplaceAll(buf.Bytes(), nil), nil
+}
+
+// MarshalAll converts several Configs to a multi-document YAML stream, one
+// document per config. The stream can't be launched as a whole, so unlike
+// Marshal the headers don't say how to launch it.
+func MarshalAll(cfgs []*Config) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, cfg := range cfgs {
+		data, err := marshalDocument(cfg, fmt.Sprintf("hive configuration for the %q session", cfg.Session.Name))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
 
-	return data, nil
+	return buf.Bytes(), nil
 }
 
 // Write writes a Config to a file
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"bytes"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestParseBytes(t *testing.T) {
@@ -113,6 +118,10 @@ env:
 	if cfg.Windows[0].Name != "main" {
 		t.Errorf("Windows[0].Name: got %q, want %q", cfg.Windows[0].Name, "main")
 	}
+
+	if cfg.Path != configPath {
+		t.Errorf("Path: got %q, want %q", cfg.Path, configPath)
+	}
 }
 
 func TestParseNonExistentFile(t *testing.T) {
@@ -122,6 +131,19 @@ func TestParseNonExistentFile(t *testing.T) {
 	}
 }
 
+func TestHash(t *testing.T) {
+	a := Hash([]byte("session:\n  name: a\n"))
+	if len(a) != 16 {
+		t.Errorf("Hash() = %q, want 16 hex digits", a)
+	}
+	if b := Hash([]byte("session:\n  name: a\n")); b != a {
+		t.Errorf("Hash() = %q and %q for the same contents", a, b)
+	}
+	if b := Hash([]byte("session:\n  name: b\n")); b == a {
+		t.Errorf("Hash() = %q for different contents", b)
+	}
+}
+
 func TestMarshal(t *testing.T) {
 	cfg := &Config{
 		Session: SessionConfig{
@@ -164,6 +186,124 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestMarshalCompact(t *testing.T) {
+	cfg := &Config{
+		Session: SessionConfig{Name: "api", BaseDir: "/srv/api"},
+		Windows: []WindowConfig{
+			{
+				Name: "editor",
+				Panes: []PaneConfig{
+					{Cmd: "nvim .", Dir: "/srv/api"},
+					{Dir: ".", Split: "vertical"},
+				},
+			},
+			{
+				Name:    "server",
+				Dir:     "cmd/server",
+				Options: map[string]interface{}{"synchronize-panes": true},
+				Panes: []PaneConfig{
+					{Cmd: "go run .", Dir: "/srv/api/cmd/server"},
+					{Cmd: "tail -f log", Dir: "/var/log", Split: "horizontal", Size: "30%"},
+				},
+			},
+		},
+		Options:       map[string]interface{}{"mouse": true},
+		ServerOptions: map[string]interface{}{"escape-time": 10},
+		Env:           map[string]string{"PORT": "8080"},
+	}
+
+	want := `# hive configuration for the "api" session
+# Launch with: hive launch -c <file>
+
+session:
+  name: api
+  base_dir: /srv/api
+
+windows:
+  - name: editor
+    panes:
+      - nvim .
+      - ""
+
+  - name: server
+    dir: cmd/server
+    options:
+      synchronize-panes: true
+    panes:
+      - go run .
+      - cmd: tail -f log
+        dir: /var/log
+        split: horizontal
+        size: 30%
+
+options:
+  mouse: true
+
+server_options:
+  escape-time: 10
+
+env:
+  PORT: "8080"
+`
+
+	data, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	// The compact form launches the same panes
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes() error = %v", err)
+	}
+	if changes := Diff(cfg, parsed); len(changes) != 0 {
+		t.Errorf("Diff() after round trip = %v", changes)
+	}
+	if cfg.Windows[0].Panes[0].Dir != "/srv/api" {
+		t.Error("Marshal() modified the config")
+	}
+}
+
+func TestMarshalAll(t *testing.T) {
+	cfgs := []*Config{
+		{Session: SessionConfig{Name: "api"}, Windows: []WindowConfig{{Name: "main", Panes: []PaneConfig{{Cmd: "npm start"}}}}},
+		{Session: SessionConfig{Name: "docs"}, Windows: []WindowConfig{{Name: "edit", Panes: []PaneConfig{{}}}}},
+	}
+
+	data, err := MarshalAll(cfgs)
+	if err != nil {
+		t.Fatalf("MarshalAll() error = %v", err)
+	}
+
+	// Every document parses back as a standalone config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var names []string
+	for {
+		var cfg Config
+		if err := decoder.Decode(&cfg); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		names = append(names, cfg.Session.Name)
+	}
+
+	if len(names) != 2 || names[0] != "api" || names[1] != "docs" {
+		t.Errorf("documents = %v, want [api docs]", names)
+	}
+
+	// A config file holds one session, so the stream can't be launched
+	if strings.Contains(string(data), "hive launch") {
+		t.Errorf("MarshalAll() output suggests launching it:\n%s", data)
+	}
+	if _, err := ParseBytes(data); err == nil {
+		t.Error("ParseBytes() should reject several documents")
+	}
+}
+
 func TestWrite(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "test.yaml")
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveDir resolves a directory path relative to a base directory
+// A leading ~ in dir is expanded to the home directory
+func ResolveDir(baseDir, dir string) string {
+	if dir == "" {
+		return baseDir
+	}
+	dir = ExpandHome(dir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(baseDir, dir)
+}
+
+// PaneDir returns the effective working directory of a pane, taking the
+// session base directory and the window directory into account
+func (c *Config) PaneDir(window WindowConfig, pane PaneConfig) string {
+	return ResolveDir(ResolveDir(c.BaseDir(), window.Dir), pane.Dir)
+}
+
+// BaseDir returns the absolute session base directory, with ~ expanded
+// A relative base directory starts from the directory of the config file,
+// which is also the default. Configs not read from a file use the current
+// directory instead.
+func (c *Config) BaseDir() string {
+	baseDir := ExpandHome(c.Session.BaseDir)
+	if c.Path != "" {
+		baseDir = ResolveDir(filepath.Dir(c.Path), baseDir)
+	}
+	if baseDir == "" {
+		baseDir = "."
+	}
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		return abs
+	}
+	return baseDir
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// CompressHome replaces the home directory at the start of a path with ~,
+// the reverse of ExpandHome
+func CompressHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" || home == "/" {
+		return path
+	}
+
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+"/"); ok {
+		return "~/" + rest
+	}
+	return path
+}
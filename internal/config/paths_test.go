@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseDir string
+		dir     string
+		want    string
+	}{
+		{"empty dir uses base", "/srv/app", "", "/srv/app"},
+		{"relative dir joins base", "/srv/app", "api", "/srv/app/api"},
+		{"dot-relative dir", "/srv/app", "./web", "/srv/app/web"},
+		{"absolute dir wins", "/srv/app", "/tmp", "/tmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveDir(tt.baseDir, tt.dir); got != tt.want {
+				t.Errorf("ResolveDir(%q, %q) = %q, want %q", tt.baseDir, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaneDir(t *testing.T) {
+	cfg := &Config{
+		Session: SessionConfig{Name: "test", BaseDir: "/srv/app"},
+	}
+	window := WindowConfig{Name: "api", Dir: "api"}
+
+	if got := cfg.PaneDir(window, PaneConfig{}); got != "/srv/app/api" {
+		t.Errorf("PaneDir() = %q, want %q", got, "/srv/app/api")
+	}
+	if got := cfg.PaneDir(window, PaneConfig{Dir: "cmd"}); got != "/srv/app/api/cmd" {
+		t.Errorf("PaneDir() = %q, want %q", got, "/srv/app/api/cmd")
+	}
+	if got := cfg.PaneDir(window, PaneConfig{Dir: "/tmp"}); got != "/tmp" {
+		t.Errorf("PaneDir() = %q, want %q", got, "/tmp")
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", "/home/alice"},
+		{"~/src/app", "/home/alice/src/app"},
+		{"/tmp/~", "/tmp/~"},
+		{"~bob/src", "~bob/src"},
+		{"relative", "relative"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandHome(tt.path); got != tt.want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompressHome(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/alice", "~"},
+		{"/home/alice/src/app", "~/src/app"},
+		{"/home/alice2/src", "/home/alice2/src"},
+		{"/tmp/home/alice", "/tmp/home/alice"},
+		{"relative", "relative"},
+	}
+
+	for _, tt := range tests {
+		if got := CompressHome(tt.path); got != tt.want {
+			t.Errorf("CompressHome(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConfigBaseDir(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		baseDir string
+		want    string
+	}{
+		{"", cwd},
+		{"/srv/app", "/srv/app"},
+		{"~/src/app", "/home/alice/src/app"},
+		{"app", filepath.Join(cwd, "app")},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Session: SessionConfig{BaseDir: tt.baseDir}}
+		if got := cfg.BaseDir(); got != tt.want {
+			t.Errorf("BaseDir() with base_dir %q = %q, want %q", tt.baseDir, got, tt.want)
+		}
+	}
+
+	// Read from a file, relative directories start from its directory
+	fileTests := []struct {
+		baseDir string
+		want    string
+	}{
+		{"", "/srv/configs"},
+		{".", "/srv/configs"},
+		{"../app", "/srv/app"},
+		{"/srv/app", "/srv/app"},
+		{"~/src/app", "/home/alice/src/app"},
+	}
+
+	for _, tt := range fileTests {
+		cfg := &Config{Session: SessionConfig{BaseDir: tt.baseDir}, Path: "/srv/configs/.hive.yaml"}
+		if got := cfg.BaseDir(); got != tt.want {
+			t.Errorf("BaseDir() of a file with base_dir %q = %q, want %q", tt.baseDir, got, tt.want)
+		}
+	}
+}
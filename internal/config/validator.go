@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/layout"
 )
 
 // ValidationError represents a configuration validation error
@@ -43,6 +45,20 @@ func Validate(cfg *Config) error {
 		})
 	}
 
+	if cfg.Session.SocketName != "" && cfg.Session.SocketPath != "" {
+		errors = append(errors, ValidationError{
+			Field:   "session.socket_path",
+			Message: "socket_name and socket_path are mutually exclusive",
+		})
+	}
+
+	if strings.Contains(cfg.Session.SocketName, "/") {
+		errors = append(errors, ValidationError{
+			Field:   "session.socket_name",
+			Message: "socket name must not contain '/', use socket_path for a full path",
+		})
+	}
+
 	// Validate windows
 	if len(cfg.Windows) == 0 {
 		errors = append(errors, ValidationError{
@@ -61,11 +77,13 @@ func Validate(cfg *Config) error {
 		}
 
 		// Validate layout if specified
-		if window.Layout != "" && !isValidLayout(window.Layout) {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("windows[%d].layout", i),
-				Message: fmt.Sprintf("invalid layout '%s', must be one of: %s", window.Layout, strings.Join(ValidLayouts, ", ")),
-			})
+		if window.Layout != "" {
+			if err := validateLayout(window); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("windows[%d].layout", i),
+					Message: err.Error(),
+				})
+			}
 		}
 
 		// Validate panes
@@ -84,6 +102,16 @@ func Validate(cfg *Config) error {
 					Message: fmt.Sprintf("invalid split '%s', must be one of: %s", pane.Split, strings.Join(ValidSplits, ", ")),
 				})
 			}
+
+			// Validate size if specified
+			if pane.Size != "" {
+				field := fmt.Sprintf("windows[%d].panes[%d].size", i, j)
+				if _, _, err := ParseSize(pane.Size); err != nil {
+					errors = append(errors, ValidationError{Field: field, Message: err.Error()})
+				} else if j == 0 {
+					errors = append(errors, ValidationError{Field: field, Message: "the first pane fills the window and cannot be sized"})
+				}
+			}
 		}
 	}
 
@@ -94,6 +122,33 @@ func Validate(cfg *Config) error {
 	return nil
 }
 
+// validateLayout accepts a layout name or a tmux layout string that fits
+// the window's panes
+func validateLayout(window WindowConfig) error {
+	if isValidLayout(window.Layout) {
+		return nil
+	}
+
+	if !layout.IsLayout(window.Layout) {
+		return fmt.Errorf("invalid layout '%s', must be a tmux layout string or one of: %s", window.Layout, strings.Join(ValidLayouts, ", "))
+	}
+
+	cell, err := layout.Parse(window.Layout)
+	if err != nil {
+		return err
+	}
+	if err := cell.Check(); err != nil {
+		return fmt.Errorf("invalid layout: %w", err)
+	}
+
+	// A window without panes still starts with one
+	panes := max(len(window.Panes), 1)
+	if count := cell.PaneCount(); count != panes {
+		return fmt.Errorf("layout has %d pane(s) but the window has %d", count, panes)
+	}
+	return nil
+}
+
 func isValidLayout(layout string) bool {
 	for _, valid := range ValidLayouts {
 		if layout == valid {
@@ -126,6 +126,64 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "custom layout",
+			config: &Config{
+				Session: SessionConfig{
+					Name: "test",
+				},
+				Windows: []WindowConfig{
+					{
+						Name:   "main",
+						Layout: "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}",
+						Panes: []PaneConfig{
+							{Cmd: "nvim"},
+							{Cmd: "npm run dev"},
+							{Cmd: "npm test"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom layout with wrong pane count",
+			config: &Config{
+				Session: SessionConfig{
+					Name: "test",
+				},
+				Windows: []WindowConfig{
+					{
+						Name:   "main",
+						Layout: "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}",
+						Panes: []PaneConfig{
+							{Cmd: "nvim"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "layout has 3 pane(s) but the window has 1",
+		},
+		{
+			name: "custom layout with bad checksum",
+			config: &Config{
+				Session: SessionConfig{
+					Name: "test",
+				},
+				Windows: []WindowConfig{
+					{
+						Name:   "main",
+						Layout: "0000,159x48,0,0,1",
+						Panes: []PaneConfig{
+							{Cmd: "nvim"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "checksum",
+		},
 		{
 			name: "invalid split",
 			config: &Config{
@@ -225,3 +283,56 @@ func TestIsValidSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateLayoutWithoutPanes(t *testing.T) {
+	// The one pane a window starts with fits a single pane layout
+	window := WindowConfig{Name: "main", Layout: "d03e,159x48,0,0,1"}
+	if err := validateLayout(window); err != nil {
+		t.Errorf("validateLayout() error = %v", err)
+	}
+
+	window.Layout = "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}"
+	if err := validateLayout(window); err == nil || !strings.Contains(err.Error(), "the window has 1") {
+		t.Errorf("validateLayout() error = %v, want a pane count mismatch", err)
+	}
+}
+
+func TestValidatePaneSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    string
+		index   int
+		wantErr string
+	}{
+		{"percentage", "30%", 1, ""},
+		{"cells", "20", 1, ""},
+		{"zero", "0", 1, "invalid size"},
+		{"whole window", "100%", 1, "below 100%"},
+		{"negative", "-5", 1, "invalid size"},
+		{"unit", "20px", 1, "invalid size"},
+		{"first pane", "30%", 0, "cannot be sized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panes := []PaneConfig{{Cmd: "nvim"}, {Cmd: "make"}}
+			panes[tt.index].Size = tt.size
+
+			cfg := &Config{
+				Session: SessionConfig{Name: "test"},
+				Windows: []WindowConfig{{Name: "main", Panes: panes}},
+			}
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
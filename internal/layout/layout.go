@@ -0,0 +1,438 @@
+// Package layout parses and serializes tmux window layout strings, the
+// format tmux prints for #{window_layout} and accepts in select-layout:
+//
+//	bb62,159x48,0,0{79x48,0,0,1,79x48,80,0[79x24,80,0,2,79x23,80,25,3]}
+//
+// A layout starts with a checksum, followed by the root cell. Every cell is
+// WIDTHxHEIGHT,X,Y and is either a pane (",ID") or split into children laid
+// out left to right ("{...}") or top to bottom ("[...]").
+package layout
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kind is how a cell is split
+type Kind int
+
+const (
+	// Pane is a leaf cell holding a single pane
+	Pane Kind = iota
+	// LeftRight cells hold children side by side, written as {...}
+	LeftRight
+	// TopBottom cells hold children stacked vertically, written as [...]
+	TopBottom
+)
+
+// Cell is a rectangle of a window, either a pane or a split into children
+type Cell struct {
+	Kind   Kind
+	Width  int
+	Height int
+	X      int
+	Y      int
+
+	// PaneID is the tmux pane number (%ID) of a pane cell, -1 if unknown
+	PaneID int
+
+	Children []*Cell
+}
+
+// Parse parses a layout string and verifies its checksum
+func Parse(s string) (*Cell, error) {
+	sum, body, ok := strings.Cut(s, ",")
+	if !ok || len(sum) != 4 {
+		return nil, fmt.Errorf("invalid layout %q: missing checksum", s)
+	}
+
+	want, err := strconv.ParseUint(sum, 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %q: bad checksum %q", s, sum)
+	}
+	if got := Checksum(body); uint16(want) != got {
+		return nil, fmt.Errorf("invalid layout %q: checksum is %04x, want %04x", s, want, got)
+	}
+
+	p := &parser{input: body}
+	cell, err := p.cell()
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %q: %w", s, err)
+	}
+	if p.pos != len(body) {
+		return nil, fmt.Errorf("invalid layout %q: unexpected %q at offset %d", s, body[p.pos:], p.pos)
+	}
+
+	return cell, nil
+}
+
+// IsLayout reports whether s looks like a layout string rather than a
+// layout name, without fully parsing it
+func IsLayout(s string) bool {
+	sum, body, ok := strings.Cut(s, ",")
+	if !ok || len(sum) != 4 || body == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(sum, 16, 16)
+	return err == nil
+}
+
+// Checksum computes the checksum tmux prefixes layout strings with
+func Checksum(body string) uint16 {
+	var sum uint16
+	for i := 0; i < len(body); i++ {
+		sum = (sum >> 1) + ((sum & 1) << 15)
+		sum += uint16(body[i])
+	}
+	return sum
+}
+
+// String serializes the cell as a layout string, checksum included
+func (c *Cell) String() string {
+	var sb strings.Builder
+	c.write(&sb)
+	body := sb.String()
+	return fmt.Sprintf("%04x,%s", Checksum(body), body)
+}
+
+func (c *Cell) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "%dx%d,%d,%d", c.Width, c.Height, c.X, c.Y)
+
+	switch c.Kind {
+	case Pane:
+		if c.PaneID >= 0 {
+			fmt.Fprintf(sb, ",%d", c.PaneID)
+		}
+	case LeftRight, TopBottom:
+		open, close := byte('{'), byte('}')
+		if c.Kind == TopBottom {
+			open, close = '[', ']'
+		}
+		sb.WriteByte(open)
+		for i, child := range c.Children {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			child.write(sb)
+		}
+		sb.WriteByte(close)
+	}
+}
+
+// Panes returns the pane cells in layout order, which is the order tmux
+// numbers the panes of the window in
+func (c *Cell) Panes() []*Cell {
+	if c.Kind == Pane {
+		return []*Cell{c}
+	}
+
+	var panes []*Cell
+	for _, child := range c.Children {
+		panes = append(panes, child.Panes()...)
+	}
+	return panes
+}
+
+// PaneCount returns the number of panes in the layout
+func (c *Cell) PaneCount() int {
+	return len(c.Panes())
+}
+
+// Check verifies that the children of every split exactly fill it, the
+// way tmux does before applying a layout
+func (c *Cell) Check() error {
+	if c.Kind == Pane {
+		return nil
+	}
+	if len(c.Children) == 0 {
+		return fmt.Errorf("cell %dx%d,%d,%d is split but has no children", c.Width, c.Height, c.X, c.Y)
+	}
+
+	// Children are separated by a one cell border
+	size := -1
+	for _, child := range c.Children {
+		if c.Kind == LeftRight {
+			if child.Height != c.Height || child.Y != c.Y {
+				return fmt.Errorf("cell %dx%d,%d,%d does not span the height of its parent", child.Width, child.Height, child.X, child.Y)
+			}
+			size += child.Width + 1
+		} else {
+			if child.Width != c.Width || child.X != c.X {
+				return fmt.Errorf("cell %dx%d,%d,%d does not span the width of its parent", child.Width, child.Height, child.X, child.Y)
+			}
+			size += child.Height + 1
+		}
+
+		if err := child.Check(); err != nil {
+			return err
+		}
+	}
+
+	if c.Kind == LeftRight && size != c.Width {
+		return fmt.Errorf("columns of cell %dx%d,%d,%d add up to width %d", c.Width, c.Height, c.X, c.Y, size)
+	}
+	if c.Kind == TopBottom && size != c.Height {
+		return fmt.Errorf("rows of cell %dx%d,%d,%d add up to height %d", c.Width, c.Height, c.X, c.Y, size)
+	}
+	return nil
+}
+
+// parser is a recursive descent parser over a layout body
+type parser struct {
+	input string
+	pos   int
+}
+
+// cell parses WIDTHxHEIGHT,X,Y followed by a pane ID or a list of children
+func (p *parser) cell() (*Cell, error) {
+	c := &Cell{PaneID: -1}
+
+	var err error
+	if c.Width, err = p.number(); err != nil {
+		return nil, err
+	}
+	if err := p.expect('x'); err != nil {
+		return nil, err
+	}
+	if c.Height, err = p.number(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	if c.X, err = p.number(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	if c.Y, err = p.number(); err != nil {
+		return nil, err
+	}
+
+	switch p.peek() {
+	case '{', '[':
+		c.Kind = LeftRight
+		close := byte('}')
+		if p.peek() == '[' {
+			c.Kind = TopBottom
+			close = ']'
+		}
+		p.pos++
+
+		for {
+			child, err := p.cell()
+			if err != nil {
+				return nil, err
+			}
+			c.Children = append(c.Children, child)
+
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+
+		if err := p.expect(close); err != nil {
+			return nil, err
+		}
+
+	case ',':
+		// A comma followed by digits and then anything but "x" is a pane
+		// ID; otherwise it separates this cell from its next sibling
+		start := p.pos
+		p.pos++
+		id, err := p.number()
+		if err != nil || p.peek() == 'x' {
+			p.pos = start
+			break
+		}
+		c.PaneID = id
+	}
+
+	return c, nil
+}
+
+func (p *parser) number() (int, error) {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.unexpected("a number")
+	}
+	return strconv.Atoi(p.input[start:p.pos])
+}
+
+func (p *parser) expect(b byte) error {
+	if p.peek() != b {
+		return p.unexpected(fmt.Sprintf("%q", b))
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) unexpected(want string) error {
+	if p.pos >= len(p.input) {
+		return fmt.Errorf("expected %s at end of layout", want)
+	}
+	return fmt.Errorf("expected %s at offset %d, got %q", want, p.pos, p.input[p.pos])
+}
+
+// Split is how a pane is created from the pane before it in layout order
+type Split struct {
+	Kind    Kind // LeftRight for side by side panes, TopBottom for stacked panes
+	Percent int  // share of the split pane the new pane takes
+	Even    bool // the new pane takes half, the size tmux gives it by default
+}
+
+// Splits returns, for every pane after the first in layout order, the split
+// that creates it when each new pane is split off the previous one. Exact is
+// false when the layout can't be built that way, because a split other than
+// the last one in a cell holds more than one pane; the splits then only
+// approximate it.
+func (c *Cell) Splits() (splits []Split, exact bool) {
+	exact = true
+	c.splits(&splits, &exact)
+	return splits, exact
+}
+
+func (c *Cell) splits(splits *[]Split, exact *bool) {
+	if c.Kind == Pane {
+		return
+	}
+
+	// The pane being split covers the remaining children; the new pane
+	// takes all of them but the first
+	for i, child := range c.Children[:len(c.Children)-1] {
+		if child.Kind != Pane {
+			// Its panes would have to be split off a pane that is no
+			// longer the last one
+			*exact = false
+			child.approximate(splits)
+		}
+
+		rest := c.Children[i+1:]
+		remaining := -1
+		for _, next := range rest {
+			remaining += c.span(next) + 1
+		}
+		total := c.span(child) + 1 + remaining
+
+		*splits = append(*splits, Split{
+			Kind:    c.Kind,
+			Percent: (remaining*100 + total/2) / total,
+			Even:    remaining == (total+1)/2-1,
+		})
+	}
+
+	c.Children[len(c.Children)-1].splits(splits, exact)
+}
+
+// approximate appends splits in layout order that give every pane of the
+// cell the right direction, without sizes
+func (c *Cell) approximate(splits *[]Split) {
+	if c.Kind == Pane {
+		return
+	}
+	for i, child := range c.Children {
+		if i > 0 {
+			*splits = append(*splits, Split{Kind: c.Kind, Percent: 50, Even: true})
+		}
+		child.approximate(splits)
+	}
+}
+
+// span returns the size of a child along the direction of the split
+func (c *Cell) span(child *Cell) int {
+	if c.Kind == LeftRight {
+		return child.Width
+	}
+	return child.Height
+}
+
+// Presets are the layout names tmux knows, the mirrored ones of tmux 3.5
+// last since they share arrangements with the others
+var Presets = []string{
+	"even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled",
+	"main-horizontal-mirrored", "main-vertical-mirrored",
+}
+
+// Preset returns how tmux arranges a number of panes with a named layout
+// such as main-vertical, without sizes. ok is false for unknown names.
+func Preset(name string, panes int) (cell *Cell, ok bool) {
+	leaves := func(n int) []*Cell {
+		cells := make([]*Cell, n)
+		for i := range cells {
+			cells[i] = &Cell{Kind: Pane, PaneID: -1}
+		}
+		return cells
+	}
+	// A split of one pane is just the pane
+	split := func(kind Kind, children []*Cell) *Cell {
+		if len(children) == 1 {
+			return children[0]
+		}
+		return &Cell{Kind: kind, PaneID: -1, Children: children}
+	}
+
+	if !slices.Contains(Presets, name) {
+		return nil, false
+	}
+	if panes < 2 {
+		return &Cell{Kind: Pane, PaneID: -1}, true
+	}
+
+	switch name {
+	case "even-horizontal":
+		return split(LeftRight, leaves(panes)), true
+	case "even-vertical":
+		return split(TopBottom, leaves(panes)), true
+	case "main-horizontal":
+		return split(TopBottom, []*Cell{leaves(1)[0], split(LeftRight, leaves(panes-1))}), true
+	case "main-vertical":
+		return split(LeftRight, []*Cell{leaves(1)[0], split(TopBottom, leaves(panes-1))}), true
+	case "main-horizontal-mirrored":
+		return split(TopBottom, []*Cell{split(LeftRight, leaves(panes-1)), leaves(1)[0]}), true
+	case "main-vertical-mirrored":
+		return split(LeftRight, []*Cell{split(TopBottom, leaves(panes-1)), leaves(1)[0]}), true
+	}
+
+	// tiled adds rows and columns in turn until every pane fits, then
+	// fills the rows in order
+	rows, columns := 1, 1
+	for rows*columns < panes {
+		rows++
+		if rows*columns < panes {
+			columns++
+		}
+	}
+	var cells []*Cell
+	for left := panes; left > 0; left -= columns {
+		cells = append(cells, split(LeftRight, leaves(min(left, columns))))
+	}
+	return split(TopBottom, cells), true
+}
+
+// SameShape reports whether two layouts split their panes the same way,
+// whatever the sizes of the cells and the panes in them
+func (c *Cell) SameShape(other *Cell) bool {
+	if c.Kind != other.Kind || len(c.Children) != len(other.Children) {
+		return false
+	}
+	for i, child := range c.Children {
+		if !child.SameShape(other.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
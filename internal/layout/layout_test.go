@@ -0,0 +1,235 @@
+package layout
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Layouts printed by tmux 3.3a
+const (
+	threePanes = "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}"
+	singlePane = "d040,159x48,0,0,3"
+	tiledFive  = "99c8,160x48,0,0[160x15,0,0{79x15,0,0,0,80x15,80,0,1},160x15,0,16{79x15,0,16,2,80x15,80,16,3},160x16,0,32,4]"
+	mainFive   = "33a5,160x48,0,0[160x24,0,0,0,160x23,0,25{39x23,0,25,1,39x23,40,25,2,39x23,80,25,3,40x23,120,25,4}]"
+	nested     = "3c0b,159x48,0,0[159x24,0,0{111x24,0,0,3,47x24,112,0[47x12,112,0,5,47x11,112,13,6]},159x23,0,25,4]"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	for _, s := range []string{threePanes, singlePane, nested} {
+		cell, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", s, err)
+		}
+		if got := cell.String(); got != s {
+			t.Errorf("String() = %q, want %q", got, s)
+		}
+		if err := cell.Check(); err != nil {
+			t.Errorf("Check(%q) error = %v", s, err)
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	cell, err := Parse(nested)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if cell.Kind != TopBottom || len(cell.Children) != 2 {
+		t.Fatalf("root = %+v, want top-bottom split with 2 children", cell)
+	}
+
+	top := cell.Children[0]
+	if top.Kind != LeftRight || top.Width != 159 || top.Height != 24 {
+		t.Errorf("top row = %+v", top)
+	}
+
+	var ids []int
+	for _, pane := range cell.Panes() {
+		ids = append(ids, pane.PaneID)
+	}
+	if want := []int{3, 5, 6, 4}; !slices.Equal(ids, want) {
+		t.Errorf("pane order = %v, want %v", ids, want)
+	}
+
+	if cell.PaneCount() != 4 {
+		t.Errorf("PaneCount() = %d, want 4", cell.PaneCount())
+	}
+}
+
+func TestParseWithoutPaneIDs(t *testing.T) {
+	// tmux before 1.8 did not write pane IDs
+	body := "159x48,0,0{79x48,0,0,79x48,80,0}"
+	s := checksummed(body)
+
+	cell, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cell.PaneCount() != 2 || cell.Children[0].PaneID != -1 {
+		t.Errorf("Parse() = %+v", cell)
+	}
+	if got := cell.String(); got != s {
+		t.Errorf("String() = %q, want %q", got, s)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		want   string
+	}{
+		{"preset name", "tiled", "missing checksum"},
+		{"bad checksum", "0000,159x48,0,0,3", "checksum"},
+		{"unclosed split", checksummed("159x48,0,0{79x48,0,0,0"), "expected '}'"},
+		{"trailing garbage", checksummed("159x48,0,0,3]"), "unexpected"},
+		{"missing size", checksummed("159,0,0,3"), "expected 'x'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.layout)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want it to mention %q", tt.layout, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// The columns add up to 158 instead of 159
+	cell, err := Parse(checksummed("159x48,0,0{78x48,0,0,0,79x48,80,0,1}"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cell.Check(); err == nil {
+		t.Error("Check() should reject columns that do not fill the window")
+	}
+}
+
+func TestIsLayout(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{threePanes, true},
+		{"0000,garbage", true},
+		{"tiled", false},
+		{"main-vertical", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsLayout(tt.s); got != tt.want {
+			t.Errorf("IsLayout(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func checksummed(body string) string {
+	return fmt.Sprintf("%04x,%s", Checksum(body), body)
+}
+
+func TestSplits(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    string
+		want      []Split
+		wantExact bool
+	}{
+		{
+			name:      "single pane",
+			layout:    singlePane,
+			want:      nil,
+			wantExact: true,
+		},
+		{
+			name:   "main pane with stacked panes on the right",
+			layout: threePanes,
+			want: []Split{
+				{Kind: LeftRight, Percent: 50, Even: true},
+				{Kind: TopBottom, Percent: 48, Even: true},
+			},
+			wantExact: true,
+		},
+		{
+			name:   "three uneven columns",
+			layout: checksummed("100x30,0,0{19x30,0,0,1,39x30,20,0,2,40x30,60,0,3}"),
+			want: []Split{
+				{Kind: LeftRight, Percent: 80},
+				{Kind: LeftRight, Percent: 50},
+			},
+			wantExact: true,
+		},
+		{
+			name:   "full width pane below a split",
+			layout: nested,
+			want: []Split{
+				{Kind: LeftRight, Percent: 50, Even: true},
+				{Kind: TopBottom, Percent: 50, Even: true},
+				{Kind: TopBottom, Percent: 48, Even: true},
+			},
+			wantExact: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cell, err := Parse(tt.layout)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got, exact := cell.Splits()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Splits() = %v, want %v", got, tt.want)
+			}
+			if exact != tt.wantExact {
+				t.Errorf("Splits() exact = %v, want %v", exact, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestPreset(t *testing.T) {
+	tests := []struct {
+		name  string
+		panes int
+		live  string
+		want  bool
+	}{
+		{"main-vertical", 3, threePanes, true},
+		{"even-horizontal", 3, threePanes, false},
+		{"tiled", 5, tiledFive, true},
+		{"main-horizontal", 5, mainFive, true},
+		{"main-horizontal", 5, tiledFive, false},
+		{"main-vertical", 1, singlePane, true},
+		{"tiled", 4, nested, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d", tt.name, tt.panes), func(t *testing.T) {
+			live, err := Parse(tt.live)
+			if err != nil {
+				t.Fatal(err)
+			}
+			preset, ok := Preset(tt.name, tt.panes)
+			if !ok {
+				t.Fatalf("Preset(%q) is unknown", tt.name)
+			}
+			if preset.PaneCount() != tt.panes {
+				t.Errorf("Preset() has %d panes, want %d", preset.PaneCount(), tt.panes)
+			}
+			if got := live.SameShape(preset); got != tt.want {
+				t.Errorf("SameShape() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := Preset("spiral", 3); ok {
+		t.Error("Preset() should not know an unknown layout name")
+	}
+}
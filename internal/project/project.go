@@ -0,0 +1,188 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// registryFile is the file in the hive config directory mapping project
+// names to config paths
+const registryFile = "projects.yaml"
+
+// ErrNotFound is returned for a name that is neither registered nor a named
+// config
+var ErrNotFound = errors.New("project not found")
+
+// namePattern matches valid project names, which double as file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Project is a config that can be launched by name from anywhere
+type Project struct {
+	Name string
+	Path string // absolute path of the config file
+	// Registered is set for projects in the registry, which live in their
+	// own directory, and unset for named configs in the hive config
+	// directory
+	Registered bool
+}
+
+// Registry maps project names to the config files they launch
+type Registry struct {
+	Projects map[string]string `yaml:"projects"`
+
+	path string
+}
+
+// Dir returns the hive config directory
+// Uses XDG_CONFIG_HOME/hive or ~/.config/hive
+func Dir() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configHome, "hive"), nil
+}
+
+// ValidateName checks that a project name can be registered and used as a
+// file name
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid project name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if strings.TrimSuffix(registryFile, ".yaml") == name {
+		return fmt.Errorf("invalid project name %q: reserved for the registry", name)
+	}
+	return nil
+}
+
+// LoadRegistry reads the registry in dir, empty if there is none yet
+func LoadRegistry(dir string) (*Registry, error) {
+	registry := &Registry{Projects: map[string]string{}, path: filepath.Join(dir, registryFile)}
+
+	data, err := os.ReadFile(registry.path)
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project registry: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, registry); err != nil {
+		return nil, fmt.Errorf("failed to parse project registry: %w", err)
+	}
+	if registry.Projects == nil {
+		registry.Projects = map[string]string{}
+	}
+	return registry, nil
+}
+
+// Save writes the registry back to its file
+func (r *Registry) Save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal project registry: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write project registry: %w", err)
+	}
+	return nil
+}
+
+// Add registers a config file under a name. Paths under the home directory
+// are stored with ~.
+func (r *Registry) Add(name, path string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if _, ok := r.Projects[name]; ok {
+		return fmt.Errorf("project '%s' is already registered", name)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	r.Projects[name] = config.CompressHome(abs)
+	return nil
+}
+
+// Remove unregisters a project and reports whether it was registered
+func (r *Registry) Remove(name string) bool {
+	if _, ok := r.Projects[name]; !ok {
+		return false
+	}
+	delete(r.Projects, name)
+	return true
+}
+
+// List returns the registered projects and the named configs in dir, sorted
+// by name. A registered project hides a named config of the same name.
+func List(dir string) ([]Project, error) {
+	registry, err := LoadRegistry(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]Project{}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !ok || ValidateName(name) != nil {
+			continue
+		}
+		byName[name] = Project{Name: name, Path: filepath.Join(dir, entry.Name())}
+	}
+	for name, path := range registry.Projects {
+		byName[name] = Project{Name: name, Path: config.ExpandHome(path), Registered: true}
+	}
+
+	projects := make([]Project, 0, len(byName))
+	for _, project := range byName {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	return projects, nil
+}
+
+// Find returns the project with the given name: a registered project, or
+// else the named config NAME.yaml in dir
+func Find(dir, name string) (*Project, error) {
+	registry, err := LoadRegistry(dir)
+	if err != nil {
+		return nil, err
+	}
+	if path, ok := registry.Projects[name]; ok {
+		return &Project{Name: name, Path: config.ExpandHome(path), Registered: true}, nil
+	}
+
+	if ValidateName(name) == nil {
+		path := filepath.Join(dir, name+".yaml")
+		if _, err := os.Stat(path); err == nil {
+			return &Project{Name: name, Path: path}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
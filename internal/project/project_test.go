@@ -0,0 +1,151 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	dir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if want := "/tmp/config/hive"; dir != want {
+		t.Errorf("Dir() = %q, want %q", dir, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/user")
+	dir, err = Dir()
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if want := "/home/user/.config/hive"; dir != want {
+		t.Errorf("Dir() = %q, want %q", dir, want)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"api", false},
+		{"my-app_2.0", false},
+		{"", true},
+		{".hidden", true},
+		{"a/b", true},
+		{"with space", true},
+		{"projects", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateName(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "hive")
+
+	registry, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatalf("LoadRegistry() without a file error = %v", err)
+	}
+
+	apiConfig := filepath.Join(home, "code", "api", ".hive.yaml")
+	if err := registry.Add("api", apiConfig); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := registry.Add("api", "/elsewhere.yaml"); err == nil {
+		t.Error("Add() should reject a name that is already registered")
+	}
+	if err := registry.Add("bad/name", "/elsewhere.yaml"); err == nil {
+		t.Error("Add() should reject an invalid name")
+	}
+	if err := registry.Add("tools", "/opt/tools/hive.yaml"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := registry.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Paths under home are stored with ~
+	data, err := os.ReadFile(filepath.Join(dir, "projects.yaml"))
+	if err != nil {
+		t.Fatalf("registry not written: %v", err)
+	}
+	want := "projects:\n    api: ~/code/api/.hive.yaml\n    tools: /opt/tools/hive.yaml\n"
+	if string(data) != want {
+		t.Errorf("projects.yaml =\n%s\nwant\n%s", data, want)
+	}
+
+	loaded, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+	if !loaded.Remove("tools") || loaded.Remove("tools") {
+		t.Error("Remove() should report whether the project was registered")
+	}
+	if !reflect.DeepEqual(loaded.Projects, map[string]string{"api": "~/code/api/.hive.yaml"}) {
+		t.Errorf("Projects = %v after Remove()", loaded.Projects)
+	}
+}
+
+func TestListFind(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"notes.yaml", "api.yaml", "README.md", ".hidden.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("session:\n  name: x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+	// The registered project hides the named config api.yaml
+	if err := registry.Add("api", "/code/api/.hive.yaml"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := registry.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	projects, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	wantProjects := []Project{
+		{Name: "api", Path: "/code/api/.hive.yaml", Registered: true},
+		{Name: "notes", Path: filepath.Join(dir, "notes.yaml")},
+	}
+	if !reflect.DeepEqual(projects, wantProjects) {
+		t.Errorf("List() = %+v, want %+v", projects, wantProjects)
+	}
+
+	for _, want := range wantProjects {
+		project, err := Find(dir, want.Name)
+		if err != nil {
+			t.Fatalf("Find(%q) error = %v", want.Name, err)
+		}
+		if *project != want {
+			t.Errorf("Find(%q) = %+v, want %+v", want.Name, *project, want)
+		}
+	}
+
+	for _, name := range []string{"missing", "../notes", "projects"} {
+		if _, err := Find(dir, name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Find(%q) error = %v, want ErrNotFound", name, err)
+		}
+	}
+}
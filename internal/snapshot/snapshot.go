@@ -0,0 +1,241 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion is the version of the snapshot file format
+const FormatVersion = 1
+
+const (
+	// fileName is the file describing the sessions in a snapshot directory
+	fileName = "snapshot.yaml"
+	// timeLayout names snapshot directories so that they sort by age
+	timeLayout = "2006-01-02_15-04-05"
+)
+
+// ErrNoSnapshots is returned when there is no snapshot to restore
+var ErrNoSnapshots = errors.New("no snapshots found")
+
+// Snapshot is every session of a tmux server at one point in time. Each
+// snapshot is a directory holding the sessions in snapshot.yaml and the
+// scrollback of every pane in scrollback/SESSION/WINDOW-PANE.txt, numbered
+// from 0 in the order of the configs.
+type Snapshot struct {
+	Version  int              `yaml:"version"`
+	Created  time.Time        `yaml:"created"`
+	Sessions []*config.Config `yaml:"sessions"`
+
+	// Path is the snapshot directory
+	Path string `yaml:"-"`
+}
+
+// Dir returns the directory where snapshots are stored
+// Uses XDG_STATE_HOME/hive/snapshots or ~/.local/state/hive/snapshots
+func Dir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(stateHome, "hive", "snapshots"), nil
+}
+
+// Capture snapshots every session on the server of a client
+func Capture(ctx context.Context, client *tmux.Client) ([]*tmux.SessionSnapshot, error) {
+	names, err := client.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*tmux.SessionSnapshot
+	for _, name := range names {
+		session, err := client.SnapshotSession(ctx, name, tmux.ExportOptions{})
+		if errors.Is(err, tmux.ErrSessionNotFound) {
+			// Closed since the sessions were listed
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot session '%s': %w", name, err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Write stores captured sessions as a new snapshot in dir and returns it
+// Snapshots hold the unfiltered environment and pane contents, so they are
+// only readable by the user.
+func Write(dir string, created time.Time, sessions []*tmux.SessionSnapshot) (*Snapshot, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// The snapshot is written to a hidden directory and renamed into place
+	// once complete, so that an interrupted save leaves no partial snapshot
+	path, err := os.MkdirTemp(dir, ".tmp-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(path)
+
+	snapshot := &Snapshot{Version: FormatVersion, Created: created}
+	for i, session := range sessions {
+		snapshot.Sessions = append(snapshot.Sessions, session.Config)
+
+		for j, panes := range session.Scrollback {
+			for k, history := range panes {
+				if strings.TrimSpace(history) == "" {
+					continue
+				}
+				file := scrollbackPath(path, i, j, k)
+				if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+					return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+				}
+				if err := os.WriteFile(file, []byte(history), 0600); err != nil {
+					return nil, fmt.Errorf("failed to write scrollback: %w", err)
+				}
+			}
+		}
+	}
+
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, fileName), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if snapshot.Path, err = publish(path, dir, created); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// publish renames a complete snapshot directory to its name in dir, the time
+// it was taken, numbered when another snapshot was taken in the same second
+func publish(path, dir string, created time.Time) (string, error) {
+	name := created.Format(timeLayout)
+	for n := 1; ; n++ {
+		target := filepath.Join(dir, name)
+		if n > 1 {
+			target += "-" + strconv.Itoa(n)
+		}
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		}
+		err := os.Rename(path, target)
+		if err == nil {
+			return target, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to save snapshot: %w", err)
+		}
+	}
+}
+
+// Load reads the snapshot stored in a directory
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(path, fileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := yaml.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if snapshot.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (want %d)", snapshot.Version, FormatVersion)
+	}
+
+	snapshot.Path = path
+	return &snapshot, nil
+}
+
+// List returns the names of the complete snapshots in dir, oldest first
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), fileName)); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Latest returns the path of the most recent snapshot in dir
+func Latest(dir string) (string, error) {
+	names, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", ErrNoSnapshots
+	}
+	return filepath.Join(dir, names[len(names)-1]), nil
+}
+
+// Prune removes all but the keep most recent snapshots in dir, and returns
+// the names of those removed
+func Prune(dir string, keep int) ([]string, error) {
+	names, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if keep < 1 || len(names) <= keep {
+		return nil, nil
+	}
+
+	removed := names[:len(names)-keep]
+	for _, name := range removed {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return nil, fmt.Errorf("failed to remove snapshot: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// ScrollbackFile returns the file holding the scrollback of a pane, "" if
+// none was saved
+func (s *Snapshot) ScrollbackFile(session, window, pane int) string {
+	file := scrollbackPath(s.Path, session, window, pane)
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+	return file
+}
+
+// scrollbackPath returns where the scrollback of a pane is stored
+func scrollbackPath(path string, session, window, pane int) string {
+	return filepath.Join(path, "scrollback", strconv.Itoa(session), fmt.Sprintf("%d-%d.txt", window, pane))
+}
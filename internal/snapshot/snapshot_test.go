@@ -0,0 +1,176 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/tmux"
+)
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	dir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if want := "/tmp/state/hive/snapshots"; dir != want {
+		t.Errorf("Dir() = %q, want %q", dir, want)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/user")
+	dir, err = Dir()
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if want := "/home/user/.local/state/hive/snapshots"; dir != want {
+		t.Errorf("Dir() = %q, want %q", dir, want)
+	}
+}
+
+func TestWriteLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	created := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	sessions := []*tmux.SessionSnapshot{
+		{
+			Config: &config.Config{
+				Session: config.SessionConfig{Name: "work"},
+				Windows: []config.WindowConfig{
+					{Name: "editor", Dir: "/src", Panes: []config.PaneConfig{{Cmd: "nvim"}, {}}},
+				},
+				Env: map[string]string{"API_TOKEN": "secret"},
+			},
+			Scrollback: [][]string{{"$ nvim\n", "\n"}},
+		},
+		{
+			Config: &config.Config{
+				Session: config.SessionConfig{Name: "notes", SocketName: "other"},
+				Windows: []config.WindowConfig{{Name: "main", Panes: []config.PaneConfig{{}}}},
+			},
+			Scrollback: [][]string{{"$ ls\nnotes.md\n"}},
+		},
+	}
+
+	written, err := Write(dir, created, sessions)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if want := filepath.Join(dir, "2026-10-17_09-30-00"); written.Path != want {
+		t.Errorf("Path = %q, want %q", written.Path, want)
+	}
+
+	// Nothing is left of the directory the snapshot was written to
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(written.Path) {
+		t.Errorf("snapshot directory holds %v, want only %s", entries, filepath.Base(written.Path))
+	}
+
+	info, err := os.Stat(filepath.Join(written.Path, "snapshot.yaml"))
+	if err != nil {
+		t.Fatalf("snapshot.yaml not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("snapshot.yaml mode = %o, want 600", perm)
+	}
+
+	loaded, err := Load(written.Path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !loaded.Created.Equal(created) || loaded.Version != FormatVersion {
+		t.Errorf("Load() created = %v, version = %d", loaded.Created, loaded.Version)
+	}
+	if len(loaded.Sessions) != 2 || !reflect.DeepEqual(loaded.Sessions[0].Env, sessions[0].Config.Env) {
+		t.Fatalf("Load() sessions = %+v", loaded.Sessions)
+	}
+	if loaded.Sessions[1].Session.SocketName != "other" {
+		t.Errorf("socket_name = %q, want %q", loaded.Sessions[1].Session.SocketName, "other")
+	}
+
+	tests := []struct {
+		session, window, pane int
+		want                  string
+	}{
+		{0, 0, 0, "$ nvim\n"},
+		{0, 0, 1, ""}, // blank panes aren't saved
+		{1, 0, 0, "$ ls\nnotes.md\n"},
+		{1, 0, 1, ""},
+	}
+	for _, tt := range tests {
+		file := loaded.ScrollbackFile(tt.session, tt.window, tt.pane)
+		var got string
+		if file != "" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			got = string(data)
+		}
+		if got != tt.want {
+			t.Errorf("ScrollbackFile(%d, %d, %d) holds %q, want %q", tt.session, tt.window, tt.pane, got, tt.want)
+		}
+	}
+}
+
+func TestLoadVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "snapshot.yaml"), []byte("version: 2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() should reject an unknown version")
+	}
+}
+
+func TestListPrune(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Latest(dir); !errors.Is(err, ErrNoSnapshots) {
+		t.Errorf("Latest() error = %v, want ErrNoSnapshots", err)
+	}
+
+	created := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{time.Hour, 0, 0, 2 * time.Hour} {
+		if _, err := Write(dir, created.Add(offset), nil); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	// A directory without snapshot.yaml isn't a snapshot
+	if err := os.Mkdir(filepath.Join(dir, "2026-10-17_12-00-00"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []string{"2026-10-17_09-30-00", "2026-10-17_09-30-00-2", "2026-10-17_10-30-00", "2026-10-17_11-30-00"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %q, want %q", names, want)
+	}
+
+	latest, err := Latest(dir)
+	if err != nil || latest != filepath.Join(dir, "2026-10-17_11-30-00") {
+		t.Errorf("Latest() = %q, %v", latest, err)
+	}
+
+	removed, err := Prune(dir, 2)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if !reflect.DeepEqual(removed, want[:2]) {
+		t.Errorf("Prune() removed %q, want %q", removed, want[:2])
+	}
+	if names, _ := List(dir); !reflect.DeepEqual(names, want[2:]) {
+		t.Errorf("List() after Prune() = %q, want %q", names, want[2:])
+	}
+}
@@ -0,0 +1,183 @@
+package tmux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// DefaultTimeout bounds every tmux invocation made through a Client
+const DefaultTimeout = 10 * time.Second
+
+// Executor runs a single tmux invocation and returns its standard output
+// Args never include the "tmux" binary itself. Failures reported by tmux
+// should be returned as a *CommandError so callers can match their kind.
+type Executor interface {
+	Execute(ctx context.Context, args []string) ([]byte, error)
+}
+
+// ExecExecutor runs tmux as a child process
+type ExecExecutor struct {
+	// Path is the tmux binary to run, "tmux" from $PATH if empty
+	Path string
+}
+
+// Execute runs tmux with the given arguments
+// A non-zero exit is reported as a *CommandError carrying tmux's stderr
+func (e ExecExecutor) Execute(ctx context.Context, args []string) ([]byte, error) {
+	path := e.Path
+	if path == "" {
+		path = "tmux"
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return output, newCommandError(args, exitErr.ExitCode(), stderr.String())
+	}
+	return output, err
+}
+
+// Client talks to a tmux server through an Executor
+// Every call is bound by the caller's context and the client timeout
+type Client struct {
+	Executor Executor
+	Timeout  time.Duration
+
+	// Server selection, passed before every command as -L, -S and -f
+	// The default server is used when all three are empty
+	SocketName string
+	SocketPath string
+	ConfigFile string
+
+	version *Version // cached by Version
+}
+
+// NewClient returns a client that runs the tmux binary with the default timeout
+func NewClient() *Client {
+	return &Client{
+		Executor: ExecExecutor{},
+		Timeout:  DefaultTimeout,
+	}
+}
+
+// NewSessionClient returns a client for the server a session is configured for
+func NewSessionClient(session config.SessionConfig) *Client {
+	client := NewClient()
+	client.SocketName = session.SocketName
+	client.SocketPath = config.ExpandHome(session.SocketPath)
+	client.ConfigFile = config.ExpandHome(session.TmuxConf)
+	return client
+}
+
+// CustomServer reports whether the client targets a server other than the default
+func (c *Client) CustomServer() bool {
+	return c.SocketName != "" || c.SocketPath != ""
+}
+
+// ServerArgs returns the tmux flags selecting the client's server
+func (c *Client) ServerArgs() []string {
+	var args []string
+	if c.SocketName != "" {
+		args = append(args, "-L", c.SocketName)
+	}
+	if c.SocketPath != "" {
+		args = append(args, "-S", c.SocketPath)
+	}
+	if c.ConfigFile != "" {
+		args = append(args, "-f", c.ConfigFile)
+	}
+	return args
+}
+
+// Command returns an interactive tmux command wired to the terminal, for
+// attaching or switching clients on the client's server
+func (c *Client) Command(args ...string) *exec.Cmd {
+	cmd := exec.Command(c.binary(), append(c.ServerArgs(), args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// ServerSocket returns the socket path of the server the client talks to
+func (c *Client) ServerSocket(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "display-message", "-p", "#{socket_path}")
+	if err != nil {
+		return "", fmt.Errorf("failed to get server socket: %w", err)
+	}
+	return output, nil
+}
+
+// CurrentServer returns the -L name or -S path of the server hive is
+// running inside, or empty strings for the default server or outside tmux
+func CurrentServer() (socketName, socketPath string) {
+	tmuxEnv := os.Getenv("TMUX")
+	if tmuxEnv == "" {
+		return "", ""
+	}
+
+	socket := strings.SplitN(tmuxEnv, ",", 2)[0]
+	dir := filepath.Dir(socket)
+	if dir != socketDir() {
+		return "", socket
+	}
+	if name := filepath.Base(socket); name != "default" {
+		return name, ""
+	}
+	return "", ""
+}
+
+// socketDir is where tmux keeps the sockets selected with -L
+func socketDir() string {
+	tmpDir := os.Getenv("TMUX_TMPDIR")
+	if tmpDir == "" {
+		tmpDir = "/tmp"
+	}
+	return filepath.Join(tmpDir, fmt.Sprintf("tmux-%d", os.Getuid()))
+}
+
+// binary returns the tmux binary the client runs
+func (c *Client) binary() string {
+	if e, ok := c.Executor.(ExecExecutor); ok && e.Path != "" {
+		return e.Path
+	}
+	return "tmux"
+}
+
+// run executes a tmux command and returns its output with surrounding
+// whitespace trimmed. Output printed before a failure is returned along
+// with the error.
+func (c *Client) run(ctx context.Context, args ...string) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	output, err := c.Executor.Execute(ctx, append(c.ServerArgs(), args...))
+	trimmed := strings.TrimSpace(string(output))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return trimmed, fmt.Errorf("tmux %s timed out after %s", args[0], c.Timeout)
+		}
+		if ctx.Err() != nil {
+			return trimmed, ctx.Err()
+		}
+		return trimmed, err
+	}
+
+	return trimmed, nil
+}
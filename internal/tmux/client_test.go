@@ -0,0 +1,211 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// funcExecutor adapts a function to the Executor interface
+type funcExecutor func(ctx context.Context, args []string) ([]byte, error)
+
+func (f funcExecutor) Execute(ctx context.Context, args []string) ([]byte, error) {
+	return f(ctx, args)
+}
+
+func TestClientRun(t *testing.T) {
+	var gotArgs []string
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			gotArgs = args
+			return []byte("  mysession\n"), nil
+		}),
+	}
+
+	output, err := client.run(context.Background(), "display-message", "-p", "#{session_name}")
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if output != "mysession" {
+		t.Errorf("run() = %q, want %q", output, "mysession")
+	}
+
+	if strings.Join(gotArgs, " ") != "display-message -p #{session_name}" {
+		t.Errorf("executor args = %q", gotArgs)
+	}
+}
+
+func TestClientRunTimeout(t *testing.T) {
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		Timeout: 10 * time.Millisecond,
+	}
+
+	_, err := client.run(context.Background(), "list-windows")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("run() error = %v, want timeout error", err)
+	}
+}
+
+func TestClientRunCancelled(t *testing.T) {
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		Timeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.run(ctx, "list-windows")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("run() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestSessionExists(t *testing.T) {
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			if args[0] == "has-session" && args[2] == "dev" {
+				return nil, nil
+			}
+			return nil, errors.New("exit status 1")
+		}),
+	}
+
+	if !client.SessionExists(context.Background(), "dev") {
+		t.Error("SessionExists(dev) = false, want true")
+	}
+	if client.SessionExists(context.Background(), "other") {
+		t.Error("SessionExists(other) = true, want false")
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	sessions, err := client.ListSessions(ctx)
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("ListSessions() without a server = %v, %v, want none", sessions, err)
+	}
+
+	for _, name := range []string{"api", "docs"} {
+		cfg := launchTestConfig()
+		cfg.Session.Name = name
+		if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+			t.Fatalf("Launch() error = %v", err)
+		}
+	}
+
+	sessions, err = client.ListSessions(ctx)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0] != "api" || sessions[1] != "docs" {
+		t.Errorf("ListSessions() = %v, want [api docs]", sessions)
+	}
+}
+
+func TestListWindowsNameWithSeparator(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	cfg.Windows[1].Name = "api: v2"
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	windows, err := client.ListWindows(ctx, "test")
+	if err != nil {
+		t.Fatalf("ListWindows() error = %v", err)
+	}
+	if len(windows) != 2 || windows[1].Name != "api: v2" || windows[0].HiveLayout != "main-vertical" {
+		t.Errorf("ListWindows() = %+v", windows)
+	}
+}
+
+func TestClientServerArgs(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+
+	tests := []struct {
+		name    string
+		session config.SessionConfig
+		want    string
+	}{
+		{
+			name:    "default server",
+			session: config.SessionConfig{Name: "dev"},
+			want:    "has-session -t dev",
+		},
+		{
+			name:    "socket name",
+			session: config.SessionConfig{Name: "dev", SocketName: "work"},
+			want:    "-L work has-session -t dev",
+		},
+		{
+			name:    "socket path and config",
+			session: config.SessionConfig{Name: "dev", SocketPath: "~/.tmux/ctf.sock", TmuxConf: "~/.tmux/ctf.conf"},
+			want:    "-S /home/alice/.tmux/ctf.sock -f /home/alice/.tmux/ctf.conf has-session -t dev",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			client := NewSessionClient(tt.session)
+			client.Executor = funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+				gotArgs = args
+				return nil, nil
+			})
+
+			client.SessionExists(context.Background(), tt.session.Name)
+			if got := strings.Join(gotArgs, " "); got != tt.want {
+				t.Errorf("executor args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentServer(t *testing.T) {
+	t.Setenv("TMUX_TMPDIR", "/run/user/1000")
+	dir := fmt.Sprintf("/run/user/1000/tmux-%d", os.Getuid())
+
+	tests := []struct {
+		name       string
+		tmux       string
+		wantName   string
+		wantSocket string
+	}{
+		{"outside tmux", "", "", ""},
+		{"default server", dir + "/default,1234,0", "", ""},
+		{"named server", dir + "/work,1234,0", "work", ""},
+		{"socket path", "/home/alice/ctf.sock,1234,0", "", "/home/alice/ctf.sock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", tt.tmux)
+
+			gotName, gotSocket := CurrentServer()
+			if gotName != tt.wantName || gotSocket != tt.wantSocket {
+				t.Errorf("CurrentServer() = (%q, %q), want (%q, %q)", gotName, gotSocket, tt.wantName, tt.wantSocket)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package tmux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Notifications sent by tmux in control mode. See CONTROL MODE in tmux(1)
+// for the full list; unknown notifications are delivered as-is.
+const (
+	EventOutput               = "output"
+	EventWindowAdd            = "window-add"
+	EventWindowClose          = "window-close"
+	EventWindowRenamed        = "window-renamed"
+	EventLayoutChange         = "layout-change"
+	EventPaneModeChanged      = "pane-mode-changed"
+	EventSessionChanged       = "session-changed"
+	EventSessionRenamed       = "session-renamed"
+	EventSessionsChanged      = "sessions-changed"
+	EventSessionWindowChanged = "session-window-changed"
+	EventExit                 = "exit"
+)
+
+// eventBuffer is how many notifications are queued before new ones are dropped
+const eventBuffer = 256
+
+// ErrControlClosed is returned for commands sent after the control client exited
+var ErrControlClosed = errors.New("tmux control client closed")
+
+// Event is an asynchronous notification from a control-mode client
+type Event struct {
+	Name string   // notification name without the leading %
+	Args []string // space-separated arguments
+	Raw  string   // the full line as sent by tmux
+}
+
+// ControlError is a command failure reported in a %error block
+type ControlError struct {
+	Command string
+	Output  []string
+}
+
+func (e *ControlError) Error() string {
+	return fmt.Sprintf("tmux %s: %s", e.Command, strings.Join(e.Output, "; "))
+}
+
+// ControlClient is a long-lived tmux control-mode (tmux -C) connection.
+// Commands are answered in the order they were sent; notifications are
+// delivered on the Events channel.
+type ControlClient struct {
+	writer io.Writer
+	events chan Event
+
+	mu      sync.Mutex
+	pending []*controlRequest
+	closed  bool
+	err     error
+	skip    int // unsolicited reply blocks still to be dropped
+
+	done chan struct{}
+	cmd  *exec.Cmd
+}
+
+type controlRequest struct {
+	command string
+	reply   chan controlReply
+}
+
+type controlReply struct {
+	output []string
+	err    error
+}
+
+// Control attaches a control-mode client to a session. The client stays
+// connected until Close is called, the context is cancelled or tmux exits.
+func (c *Client) Control(ctx context.Context, sessionName string) (*ControlClient, error) {
+	args := append(c.ServerArgs(), "-C", "attach-session", "-t", sessionName)
+	cmd := exec.CommandContext(ctx, c.binary(), args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open control client input: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open control client output: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start control client: %w", err)
+	}
+
+	control := newControlClient(stdout, stdin, 1)
+	control.cmd = cmd
+	return control, nil
+}
+
+// NewControlClient speaks the control-mode protocol over an existing
+// connection, for example a pipe to `tmux -C`
+func NewControlClient(r io.Reader, w io.Writer) *ControlClient {
+	return newControlClient(r, w, 0)
+}
+
+// newControlClient starts reading r; the first skip reply blocks are
+// dropped, which covers the reply to the command tmux -C was started with
+func newControlClient(r io.Reader, w io.Writer, skip int) *ControlClient {
+	control := &ControlClient{
+		writer: w,
+		events: make(chan Event, eventBuffer),
+		skip:   skip,
+		done:   make(chan struct{}),
+	}
+
+	go control.read(r)
+	return control
+}
+
+// Events returns the channel notifications are delivered on. It is closed
+// when the connection ends. Notifications are dropped if it is not drained.
+func (cc *ControlClient) Events() <-chan Event {
+	return cc.events
+}
+
+// Done is closed when the connection ends
+func (cc *ControlClient) Done() <-chan struct{} {
+	return cc.done
+}
+
+// Err returns why the connection ended, or nil while it is open or after
+// a clean %exit
+func (cc *ControlClient) Err() error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.err
+}
+
+// Command sends a tmux command and waits for its output
+func (cc *ControlClient) Command(ctx context.Context, args ...string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command given")
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteCommandArg(arg)
+	}
+	line := strings.Join(quoted, " ")
+
+	request := &controlRequest{command: args[0], reply: make(chan controlReply, 1)}
+
+	cc.mu.Lock()
+	if cc.closed {
+		cc.mu.Unlock()
+		return nil, ErrControlClosed
+	}
+	// Queue before writing so the reply can never arrive first
+	cc.pending = append(cc.pending, request)
+	if _, err := io.WriteString(cc.writer, line+"\n"); err != nil {
+		cc.pending = cc.pending[:len(cc.pending)-1]
+		cc.mu.Unlock()
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+	cc.mu.Unlock()
+
+	select {
+	case reply := <-request.reply:
+		return reply.output, reply.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close detaches the control client and waits for tmux to exit
+func (cc *ControlClient) Close() error {
+	cc.mu.Lock()
+	closed := cc.closed
+	cc.mu.Unlock()
+
+	// An empty line detaches a control client
+	if !closed {
+		io.WriteString(cc.writer, "\n")
+	}
+	if closer, ok := cc.writer.(io.Closer); ok {
+		closer.Close()
+	}
+
+	if cc.cmd != nil {
+		<-cc.done
+		// tmux exits non-zero once its input is gone; that's expected
+		cc.cmd.Wait()
+	}
+
+	return nil
+}
+
+// read parses the control-mode stream until it ends
+func (cc *ControlClient) read(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var block []string
+	inBlock := false
+	blockNumber := ""
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if inBlock {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && (fields[0] == "%end" || fields[0] == "%error") && fields[2] == blockNumber {
+				cc.reply(block, fields[0] == "%error")
+				inBlock = false
+				block = nil
+				continue
+			}
+			block = append(block, line)
+			continue
+		}
+
+		if strings.HasPrefix(line, "%begin ") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				inBlock = true
+				blockNumber = fields[2]
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "%") {
+			event := parseEvent(line)
+			if event.Name == EventExit {
+				cc.finish(nil, event)
+				return
+			}
+			cc.emit(event)
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	cc.finish(err, Event{})
+}
+
+// reply hands a finished block to the oldest waiting command
+func (cc *ControlClient) reply(output []string, failed bool) {
+	cc.mu.Lock()
+	if cc.skip > 0 {
+		cc.skip--
+		cc.mu.Unlock()
+		return
+	}
+	if len(cc.pending) == 0 {
+		cc.mu.Unlock()
+		return
+	}
+	request := cc.pending[0]
+	cc.pending = cc.pending[1:]
+	cc.mu.Unlock()
+
+	var err error
+	if failed {
+		err = &ControlError{Command: request.command, Output: output}
+	}
+	request.reply <- controlReply{output: output, err: err}
+}
+
+func (cc *ControlClient) emit(event Event) {
+	select {
+	case cc.events <- event:
+	default:
+	}
+}
+
+// finish fails outstanding commands and closes the event stream
+func (cc *ControlClient) finish(err error, exit Event) {
+	cc.mu.Lock()
+	cc.closed = true
+	cc.err = err
+	pending := cc.pending
+	cc.pending = nil
+	cc.mu.Unlock()
+
+	for _, request := range pending {
+		request.reply <- controlReply{err: ErrControlClosed}
+	}
+
+	if exit.Name != "" {
+		cc.emit(exit)
+	}
+	close(cc.events)
+	close(cc.done)
+}
+
+// parseEvent splits a notification line into its name and arguments
+func parseEvent(line string) Event {
+	fields := strings.Fields(strings.TrimPrefix(line, "%"))
+	event := Event{Raw: line}
+	if len(fields) > 0 {
+		event.Name = fields[0]
+		event.Args = fields[1:]
+	}
+
+	// %output keeps its data verbatim, spaces included
+	if event.Name == EventOutput && len(fields) >= 2 {
+		prefix := "%" + EventOutput + " " + fields[1] + " "
+		event.Args = []string{fields[1], DecodeOutput(strings.TrimPrefix(line, prefix))}
+	}
+
+	return event
+}
+
+// DecodeOutput reverses the octal escaping tmux applies to %output data
+func DecodeOutput(data string) string {
+	if !strings.Contains(data, "\\") {
+		return data
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' && i+3 < len(data) {
+			if value, err := strconv.ParseUint(data[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(data[i])
+	}
+	return sb.String()
+}
+
+// quoteCommandArg quotes an argument for the tmux command parser
+func quoteCommandArg(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:%@=,^+", r))
+	}) < 0 {
+		return arg
+	}
+
+	if !strings.Contains(arg, "'") {
+		return "'" + arg + "'"
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	return `"` + replacer.Replace(arg) + `"`
+}
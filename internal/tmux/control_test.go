@@ -0,0 +1,204 @@
+package tmux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// controlPipe connects a ControlClient to a scripted tmux on the other end
+type controlPipe struct {
+	server   *io.PipeWriter // what tmux writes
+	commands *bufio.Reader  // what the client sends
+	client   *ControlClient
+}
+
+func newControlPipe(t *testing.T, skip int) *controlPipe {
+	t.Helper()
+	serverR, serverW := io.Pipe()
+	clientR, clientW := io.Pipe()
+
+	pipe := &controlPipe{
+		server:   serverW,
+		commands: bufio.NewReader(clientR),
+		client:   newControlClient(serverR, clientW, skip),
+	}
+	t.Cleanup(func() {
+		serverW.Close()
+		clientR.Close()
+	})
+	return pipe
+}
+
+func (p *controlPipe) send(t *testing.T, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if _, err := io.WriteString(p.server, line+"\n"); err != nil {
+			t.Fatalf("write to client: %v", err)
+		}
+	}
+}
+
+func (p *controlPipe) expectCommand(t *testing.T, want string) {
+	t.Helper()
+	line, err := p.commands.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read command: %v", err)
+	}
+	if got := strings.TrimSuffix(line, "\n"); got != want {
+		t.Errorf("command sent = %q, want %q", got, want)
+	}
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestControlCommand(t *testing.T) {
+	pipe := newControlPipe(t, 1)
+	ctx := testContext(t)
+
+	result := make(chan []string, 1)
+	go func() {
+		output, err := pipe.client.Command(ctx, "list-panes", "-F", "#{pane_id} #{pane_current_command}")
+		if err != nil {
+			t.Errorf("Command() error = %v", err)
+		}
+		result <- output
+	}()
+
+	pipe.expectCommand(t, `list-panes -F '#{pane_id} #{pane_current_command}'`)
+	pipe.send(t,
+		// Reply to the attach command tmux -C was started with
+		"%begin 1700000000 100 0",
+		"%end 1700000000 100 0",
+		"%begin 1700000000 101 1",
+		"%1 bash",
+		"%2 nvim",
+		"%end 1700000000 101 1",
+	)
+
+	output := <-result
+	if strings.Join(output, "|") != "%1 bash|%2 nvim" {
+		t.Errorf("Command() output = %q", output)
+	}
+}
+
+func TestControlCommandError(t *testing.T) {
+	pipe := newControlPipe(t, 0)
+	ctx := testContext(t)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := pipe.client.Command(ctx, "kill-window", "-t", "nope:9")
+		result <- err
+	}()
+
+	pipe.expectCommand(t, "kill-window -t nope:9")
+	pipe.send(t,
+		"%begin 1700000000 7 1",
+		"can't find window: 9",
+		"%error 1700000000 7 1",
+	)
+
+	err := <-result
+	var controlErr *ControlError
+	if !errors.As(err, &controlErr) {
+		t.Fatalf("Command() error = %v, want *ControlError", err)
+	}
+	if controlErr.Output[0] != "can't find window: 9" {
+		t.Errorf("ControlError output = %q", controlErr.Output)
+	}
+}
+
+func TestControlEvents(t *testing.T) {
+	pipe := newControlPipe(t, 0)
+
+	pipe.send(t,
+		"%window-add @4",
+		"%session-changed $1 dev",
+		`%output %3 hello\040world\015\012`,
+		"%pane-mode-changed %3",
+		"%exit detached",
+	)
+
+	var events []Event
+	for event := range pipe.client.Events() {
+		events = append(events, event)
+	}
+
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5: %+v", len(events), events)
+	}
+
+	if events[0].Name != EventWindowAdd || events[0].Args[0] != "@4" {
+		t.Errorf("window-add event = %+v", events[0])
+	}
+	if events[1].Name != EventSessionChanged || strings.Join(events[1].Args, " ") != "$1 dev" {
+		t.Errorf("session-changed event = %+v", events[1])
+	}
+	if events[2].Name != EventOutput || events[2].Args[0] != "%3" || events[2].Args[1] != "hello world\r\n" {
+		t.Errorf("output event = %+v", events[2])
+	}
+	if events[3].Name != EventPaneModeChanged {
+		t.Errorf("pane-mode-changed event = %+v", events[3])
+	}
+	if events[4].Name != EventExit || events[4].Args[0] != "detached" {
+		t.Errorf("exit event = %+v", events[4])
+	}
+
+	if err := pipe.client.Err(); err != nil {
+		t.Errorf("Err() after %%exit = %v, want nil", err)
+	}
+}
+
+func TestControlExitFailsPending(t *testing.T) {
+	pipe := newControlPipe(t, 0)
+	ctx := testContext(t)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := pipe.client.Command(ctx, "list-windows")
+		result <- err
+	}()
+
+	pipe.expectCommand(t, "list-windows")
+	pipe.send(t, "%exit")
+
+	if err := <-result; !errors.Is(err, ErrControlClosed) {
+		t.Errorf("Command() error = %v, want ErrControlClosed", err)
+	}
+
+	<-pipe.client.Done()
+	if _, err := pipe.client.Command(ctx, "list-windows"); !errors.Is(err, ErrControlClosed) {
+		t.Errorf("Command() after exit error = %v, want ErrControlClosed", err)
+	}
+}
+
+func TestQuoteCommandArg(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"list-windows", "list-windows"},
+		{"dev:1.0", "dev:1.0"},
+		{"%3", "%3"},
+		{"", "''"},
+		{"npm run dev", "'npm run dev'"},
+		{"#{pane_id}", "'#{pane_id}'"},
+		{"it's", `"it's"`},
+		{`say "$HOME" it's`, `"say \"\$HOME\" it's"`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteCommandArg(tt.arg); got != tt.want {
+			t.Errorf("quoteCommandArg(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tmux
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// RedactedValue replaces the value of secret environment variables on export
+const RedactedValue = "<redacted>"
+
+// DefaultEnvDeny are the environment variables left out of exports: those
+// tmux copies from the attaching client (see update-environment) and others
+// that only make sense in the desktop or SSH session they came from
+var DefaultEnvDeny = []string{
+	"DISPLAY",
+	"WAYLAND_DISPLAY",
+	"XAUTHORITY",
+	"WINDOWID",
+	"KRB5CCNAME",
+	"SSH_ASKPASS",
+	"SSH_AUTH_SOCK",
+	"SSH_AGENT_PID",
+	"SSH_CONNECTION",
+	"SSH_CLIENT",
+	"SSH_TTY",
+	"DBUS_SESSION_BUS_ADDRESS",
+	"XDG_SESSION_ID",
+	"XDG_RUNTIME_DIR",
+	"TMUX",
+	"TMUX_PANE",
+}
+
+// DefaultEnvRedact are the patterns of environment variables whose values are
+// redacted on export
+var DefaultEnvRedact = []string{
+	"*_TOKEN",
+	"*_KEY",
+	"*PASSWORD*",
+	"*SECRET*",
+}
+
+// filterEnv returns the session environment worth exporting: variables
+// inherited unchanged from the global environment and denied ones are
+// dropped, secret ones are redacted. Patterns are shell globs matched
+// against the variable name, ignoring case.
+func filterEnv(env, global map[string]string, deny, redact []string) map[string]string {
+	filtered := make(map[string]string)
+	for key, value := range env {
+		if globalValue, ok := global[key]; ok && globalValue == value {
+			continue
+		}
+		if matchEnv(key, deny) {
+			continue
+		}
+		if matchEnv(key, redact) {
+			value = RedactedValue
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
+// matchEnv reports whether a variable name matches any of the patterns
+func matchEnv(name string, patterns []string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getGlobalEnv retrieves the global environment variables
+func (c *Client) getGlobalEnv(ctx context.Context) (map[string]string, error) {
+	return c.showEnvironment(ctx, "-g")
+}
@@ -0,0 +1,108 @@
+package tmux
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+func TestFilterEnv(t *testing.T) {
+	env := map[string]string{
+		"NODE_ENV":      "development",
+		"PATH":          "/usr/bin:/bin",
+		"HOME":          "/home/other",
+		"SSH_AUTH_SOCK": "/tmp/ssh-x/agent.1",
+		"DISPLAY":       ":0",
+		"GITHUB_TOKEN":  "ghp_abc",
+		"aws_key":       "AKIA",
+		"DB_PASSWORD_1": "hunter2",
+		"KEYBOARD":      "us",
+		"DEPLOY_HOST":   "prod.example.com",
+	}
+	global := map[string]string{
+		"PATH": "/usr/bin:/bin",
+		"HOME": "/home/dev",
+	}
+
+	tests := []struct {
+		name   string
+		deny   []string
+		redact []string
+		want   map[string]string
+	}{
+		{
+			name:   "defaults",
+			deny:   DefaultEnvDeny,
+			redact: DefaultEnvRedact,
+			want: map[string]string{
+				"NODE_ENV":      "development",
+				"HOME":          "/home/other",
+				"GITHUB_TOKEN":  RedactedValue,
+				"aws_key":       RedactedValue,
+				"DB_PASSWORD_1": RedactedValue,
+				"KEYBOARD":      "us",
+				"DEPLOY_HOST":   "prod.example.com",
+			},
+		},
+		{
+			name:   "custom patterns",
+			deny:   []string{"DEPLOY_*", "HOME"},
+			redact: []string{"NODE_*"},
+			want: map[string]string{
+				"NODE_ENV":      RedactedValue,
+				"SSH_AUTH_SOCK": "/tmp/ssh-x/agent.1",
+				"DISPLAY":       ":0",
+				"GITHUB_TOKEN":  "ghp_abc",
+				"aws_key":       "AKIA",
+				"DB_PASSWORD_1": "hunter2",
+				"KEYBOARD":      "us",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterEnv(env, global, tt.deny, tt.redact); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportEnv(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	cfg.Env = map[string]string{
+		"NODE_ENV":      "development",
+		"PATH":          "/usr/bin:/bin",
+		"SSH_AUTH_SOCK": "/tmp/ssh-x/agent.1",
+		"API_TOKEN":     "secret",
+		"REGION":        "eu-west-1",
+	}
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{EnvDeny: []string{"REGION"}})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+	want := map[string]string{"NODE_ENV": "development", "API_TOKEN": RedactedValue}
+	if !reflect.DeepEqual(exported.Env, want) {
+		t.Errorf("Env = %v, want %v", exported.Env, want)
+	}
+
+	// Comparing with the config needs the environment as is
+	exported, err = client.ExportSession(ctx, "test", ExportOptions{KeepEnv: true})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+	if changes := config.Diff(cfg, exported); len(changes) != 0 {
+		t.Errorf("exported config differs from launched config: %v", changes)
+	}
+}
@@ -0,0 +1,108 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of tmux failures, matched with errors.Is against a CommandError
+var (
+	ErrNoServer        = errors.New("no tmux server running")
+	ErrSessionNotFound = errors.New("tmux session not found")
+	ErrSessionExists   = errors.New("tmux session already exists")
+	ErrTargetNotFound  = errors.New("tmux window or pane not found")
+	ErrInvalidOption   = errors.New("invalid tmux option")
+	ErrPaneTooSmall    = errors.New("not enough space for a new tmux pane")
+)
+
+// errorPatterns maps stderr fragments to failure kinds, across the wording
+// of different tmux releases
+var errorPatterns = []struct {
+	fragment string
+	kind     error
+}{
+	{"no server running", ErrNoServer},
+	{"error connecting to", ErrNoServer},
+	{"can't find session", ErrSessionNotFound},
+	{"session not found", ErrSessionNotFound},
+	{"duplicate session", ErrSessionExists},
+	{"can't find window", ErrTargetNotFound},
+	{"can't find pane", ErrTargetNotFound},
+	{"invalid option", ErrInvalidOption},
+	{"unknown option", ErrInvalidOption},
+	{"ambiguous option", ErrInvalidOption},
+	{"bad value", ErrInvalidOption},
+	{"value is invalid", ErrInvalidOption},
+	{"no space for new pane", ErrPaneTooSmall},
+	{"pane too small", ErrPaneTooSmall},
+}
+
+// CommandError is a tmux invocation that exited with an error
+type CommandError struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Kind     error // one of the Err* kinds, nil if unclassified
+}
+
+// newCommandError builds a CommandError and classifies it by its stderr
+func newCommandError(args []string, exitCode int, stderr string) *CommandError {
+	stderr = strings.TrimSpace(stderr)
+	return &CommandError{
+		Args:     args,
+		ExitCode: exitCode,
+		Stderr:   stderr,
+		Kind:     classifyError(stderr),
+	}
+}
+
+func (e *CommandError) Error() string {
+	prefix := "tmux"
+	if command := e.command(); command != "" {
+		prefix += " " + command
+	}
+
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s: exit status %d", prefix, e.ExitCode)
+	}
+	return fmt.Sprintf("%s: %s", prefix, e.Stderr)
+}
+
+// Is matches the error against its kind
+func (e *CommandError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// command returns the tmux command that was run, skipping server flags
+// It is empty for chained commands, where tmux doesn't say which one failed
+func (e *CommandError) command() string {
+	for _, arg := range e.Args {
+		if arg == ";" {
+			return ""
+		}
+	}
+
+	for i := 0; i < len(e.Args); i++ {
+		arg := e.Args[i]
+		switch {
+		case arg == "-L" || arg == "-S" || arg == "-f":
+			i++
+		case !strings.HasPrefix(arg, "-"):
+			return arg
+		}
+	}
+	return strings.Join(e.Args, " ")
+}
+
+// classifyError returns the kind of failure a tmux error message reports
+func classifyError(stderr string) error {
+	// Chained commands report the first failure on the first line
+	message := strings.ToLower(strings.SplitN(stderr, "\n", 2)[0])
+	for _, pattern := range errorPatterns {
+		if strings.Contains(message, pattern.fragment) {
+			return pattern.kind
+		}
+	}
+	return nil
+}
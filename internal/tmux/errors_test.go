@@ -0,0 +1,118 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{"no server running on /tmp/tmux-1000/default", ErrNoServer},
+		{"error connecting to /tmp/tmux-1000/work (No such file or directory)", ErrNoServer},
+		{"can't find session: dev", ErrSessionNotFound},
+		{"session not found: dev", ErrSessionNotFound},
+		{"duplicate session: dev", ErrSessionExists},
+		{"can't find window: 9", ErrTargetNotFound},
+		{"can't find pane: %12", ErrTargetNotFound},
+		{"invalid option: bogus", ErrInvalidOption},
+		{"unknown option: bogus", ErrInvalidOption},
+		{"bad value: maybe", ErrInvalidOption},
+		{"value is invalid: abc", ErrInvalidOption},
+		{"no space for new pane", ErrPaneTooSmall},
+		{"create pane failed: pane too small", ErrPaneTooSmall},
+		{"no current client", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stderr, func(t *testing.T) {
+			if got := classifyError(tt.stderr); got != tt.want {
+				t.Errorf("classifyError(%q) = %v, want %v", tt.stderr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *CommandError
+		want string
+	}{
+		{
+			err:  newCommandError([]string{"split-window", "-t", "dev:"}, 1, "no space for new pane\n"),
+			want: "tmux split-window: no space for new pane",
+		},
+		{
+			err:  newCommandError([]string{"-L", "work", "-f", "work.conf", "kill-session", "-t", "dev"}, 1, "can't find session: dev"),
+			want: "tmux kill-session: can't find session: dev",
+		},
+		{
+			err:  newCommandError([]string{"new-window", "-t", "dev", ";", "split-window", "-t", "dev:"}, 1, "no space for new pane"),
+			want: "tmux: no space for new pane",
+		},
+		{
+			err:  newCommandError([]string{"list-windows"}, 2, ""),
+			want: "tmux list-windows: exit status 2",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestClientErrorKinds(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	err := client.KillSession(ctx, "dev")
+	if !errors.Is(err, ErrNoServer) {
+		t.Errorf("KillSession() without server error = %v, want ErrNoServer", err)
+	}
+
+	if err := client.CreateSession(ctx, "other", "", nil); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	err = client.KillSession(ctx, "dev")
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("KillSession() error = %v, want ErrSessionNotFound", err)
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Stderr != "can't find session: dev" {
+		t.Errorf("KillSession() error = %#v, want CommandError with stderr", err)
+	}
+}
+
+func TestExecExecutorCommandError(t *testing.T) {
+	// A stand-in tmux that fails the way tmux does
+	path := filepath.Join(t.TempDir(), "tmux")
+	script := "#!/bin/sh\necho \"can't find session: $3\" >&2\nexit 1\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{Executor: ExecExecutor{Path: path}}
+	err := client.KillSession(context.Background(), "dev")
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("KillSession() error = %v, want CommandError", err)
+	}
+	if cmdErr.ExitCode != 1 || cmdErr.Stderr != "can't find session: dev" {
+		t.Errorf("CommandError = %+v", cmdErr)
+	}
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("KillSession() error = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -1,127 +1,346 @@
 package tmux
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/layout"
 )
 
+// ExportOptions controls how a session is exported
+type ExportOptions struct {
+	// Shells are extra programs a pane idles in, on top of the common
+	// shells and $SHELL. Panes at a shell prompt are exported without a
+	// command.
+	Shells []string
+
+	// KeepDefaults exports every option set on the session and its windows
+	// and every server option, including those equal to the defaults they
+	// override. Comparing with a config needs them; a config to relaunch
+	// from doesn't.
+	KeepDefaults bool
+
+	// EnvDeny and EnvRedact are patterns of environment variables to leave
+	// out and to redact, on top of DefaultEnvDeny and DefaultEnvRedact
+	EnvDeny   []string
+	EnvRedact []string
+
+	// KeepEnv exports the session environment as is, without dropping
+	// inherited or denied variables and without redacting secrets
+	KeepEnv bool
+
+	// BaseDir is the session base directory to write window directories
+	// relative to, instead of the directory all panes share. "/" keeps
+	// them absolute.
+	BaseDir string
+
+	// ExactLayouts records the tmux layout string of every window with more
+	// than one pane, so that it is rebuilt to the cell
+	ExactLayouts bool
+
+	// Windows picks the windows to export by index and what to capture of
+	// each. Every window is exported in full when it is nil.
+	Windows map[string]WindowCapture
+}
+
+// WindowCapture is what to capture of an exported window besides its panes
+type WindowCapture struct {
+	Commands bool // the commands running in the panes
+	Dirs     bool // the working directories of the panes
+}
+
 // Export captures the current tmux session and converts it to a Config
-func Export() (*config.Config, error) {
+func (c *Client) Export(ctx context.Context, opts ExportOptions) (*config.Config, error) {
 	// Get current session name
-	sessionName, err := GetCurrentSession()
+	sessionName, err := c.GetCurrentSession(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current session: %w", err)
 	}
 
+	return c.ExportSession(ctx, sessionName, opts)
+}
+
+// ExportSession captures the named tmux session and converts it to a Config
+func (c *Client) ExportSession(ctx context.Context, sessionName string, opts ExportOptions) (*config.Config, error) {
+	cfg, _, err := c.exportSession(ctx, sessionName, opts)
+	return cfg, err
+}
+
+// exportSession exports a session like ExportSession and also returns the
+// panes of each exported window, in config order
+func (c *Client) exportSession(ctx context.Context, sessionName string, opts ExportOptions) (*config.Config, [][]PaneInfo, error) {
 	cfg := &config.Config{
 		Session: config.SessionConfig{
-			Name: sessionName,
+			Name:       sessionName,
+			SocketName: c.SocketName,
+			SocketPath: c.SocketPath,
+			TmuxConf:   c.ConfigFile,
 		},
 		Windows: []config.WindowConfig{},
 		Options: make(map[string]interface{}),
 		Env:     make(map[string]string),
 	}
 
-	// Get session options
-	options, err := getSessionOptions(sessionName)
-	if err == nil {
-		cfg.Options = options
+	// Get options that differ from their defaults
+	defaults, err := c.optionDefaults(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sessionOptions, err := c.GetSessionOptions(ctx, sessionName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session options: %w", err)
 	}
+	cfg.Options = nonDefaultOptions(sessionOptions, defaults.session)
+
+	serverOptions, err := c.GetServerOptions(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get server options: %w", err)
+	}
+	cfg.ServerOptions = nonDefaultOptions(serverOptions, defaults.server)
 
 	// Get environment variables
-	env, err := getSessionEnv(sessionName)
-	if err == nil {
-		cfg.Env = env
+	env, err := c.getSessionEnv(ctx, sessionName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session environment: %w", err)
 	}
+	cfg.Env = env
+	if !opts.KeepEnv {
+		global, err := c.getGlobalEnv(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get global environment: %w", err)
+		}
+		deny := slices.Concat(DefaultEnvDeny, opts.EnvDeny)
+		redact := slices.Concat(DefaultEnvRedact, opts.EnvRedact)
+		cfg.Env = filterEnv(env, global, deny, redact)
+	}
+
+	shells := shellSet(opts.Shells)
 
 	// Get windows
-	windows, err := ListWindows(sessionName)
+	windows, err := c.ListWindows(ctx, sessionName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list windows: %w", err)
+		return nil, nil, fmt.Errorf("failed to list windows: %w", err)
 	}
 
+	var exported [][]PaneInfo
 	for _, window := range windows {
+		capture := WindowCapture{Commands: true, Dirs: true}
+		if opts.Windows != nil {
+			var ok bool
+			if capture, ok = opts.Windows[window.Index]; !ok {
+				continue
+			}
+		}
+
+		// Get panes for this window
+		panes, err := c.ListPanes(ctx, sessionName, window.Index)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list panes for window %s: %w", window.Name, err)
+		}
+
+		// Rebuild the order and splits of the panes from the layout tree
+		panes, splits, exact := arrangePanes(window, panes)
+
 		windowCfg := config.WindowConfig{
 			Name:   window.Name,
-			Layout: guessLayoutName(window.Layout),
+			Layout: exportLayout(window, len(panes), splits != nil && exact),
 			Panes:  []config.PaneConfig{},
 		}
+		if opts.ExactLayouts && len(panes) > 1 && layout.IsLayout(window.Layout) {
+			windowCfg.Layout = window.Layout
+		}
 
-		// Get panes for this window
-		panes, err := ListPanes(sessionName, window.Index)
+		options, err := c.GetWindowOptions(ctx, sessionName, window.Index)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list panes for window %s: %w", window.Name, err)
+			return nil, nil, fmt.Errorf("failed to get options for window %s: %w", window.Name, err)
+		}
+		// Naming a window turns automatic renaming off, which launching the
+		// exported window by name does again
+		delete(options, "automatic-rename")
+		if options := nonDefaultOptions(options, defaults.window); len(options) > 0 {
+			windowCfg.Options = options
 		}
 
 		for i, pane := range panes {
-			paneCfg := config.PaneConfig{
-				Dir: pane.Dir,
+			paneCfg := config.PaneConfig{}
+			if capture.Dirs {
+				paneCfg.Dir = pane.Dir
 			}
-
-			// Try to get the running command (excluding shell)
-			if pane.Command != "bash" && pane.Command != "zsh" && pane.Command != "sh" {
-				paneCfg.Cmd = pane.Command
+			if capture.Commands {
+				paneCfg.Cmd = paneCommand(pane, shells)
 			}
 
-			// Set split direction for non-first panes
+			// Set split direction and size for non-first panes
 			if i > 0 {
-				// Default to vertical split (can't reliably detect from tmux state)
-				paneCfg.Split = "vertical"
+				paneCfg.Split, paneCfg.Size = exportSplit(splits, i-1, windowCfg.Layout)
 			}
 
 			windowCfg.Panes = append(windowCfg.Panes, paneCfg)
 		}
 
 		cfg.Windows = append(cfg.Windows, windowCfg)
+		exported = append(exported, panes)
 	}
 
-	return cfg, nil
+	relativizeDirs(cfg, opts.BaseDir)
+
+	return cfg, exported, nil
 }
 
-// getSessionOptions retrieves session options
-func getSessionOptions(sessionName string) (map[string]interface{}, error) {
-	options := make(map[string]interface{})
+// relativizeDirs rewrites the absolute pane directories tmux reports so the
+// config works on other machines: the base directory, by default the one all
+// panes share, becomes the session base directory, the directory the panes
+// of a window share becomes the window directory relative to it, and pane
+// directories become relative to their window, or are dropped when they are
+// the same. Paths outside the base directory stay absolute, and paths under
+// the home directory are written with ~.
+func relativizeDirs(cfg *config.Config, baseDir string) {
+	if baseDir == "" {
+		var all []string
+		for _, window := range cfg.Windows {
+			if dirs, ok := paneDirs(window); ok {
+				all = append(all, dirs...)
+			}
+		}
+		baseDir = config.CompressHome(commonDir(all))
+	}
 
-	// Get commonly used options
-	commonOptions := []string{
-		"mouse",
-		"base-index",
-		"history-limit",
+	// Paths sharing nothing but the root stay absolute
+	if baseDir != "" && baseDir != "/" {
+		cfg.Session.BaseDir = baseDir
+		baseDir = cfg.BaseDir()
+	} else {
+		baseDir = ""
 	}
 
-	for _, opt := range commonOptions {
-		cmd := exec.Command("tmux", "show-options", "-t", sessionName, opt)
-		output, err := cmd.Output()
-		if err != nil {
-			continue // Option might not be set
+	for i := range cfg.Windows {
+		window := &cfg.Windows[i]
+
+		// Windows with a pane whose directory is unknown are left alone
+		dirs, ok := paneDirs(*window)
+		if !ok {
+			continue
+		}
+
+		windowDir := commonDir(dirs)
+		switch {
+		case windowDir == "/":
+		case baseDir == "" || !withinDir(baseDir, windowDir):
+			window.Dir = config.CompressHome(windowDir)
+		case windowDir != baseDir:
+			window.Dir, _ = filepath.Rel(baseDir, windowDir)
 		}
 
-		// Parse output: "option-name value"
-		line := strings.TrimSpace(string(output))
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
-			value := strings.Join(parts[1:], " ")
-			options[opt] = value
+		for j := range window.Panes {
+			pane := &window.Panes[j]
+			if windowDir == "/" {
+				pane.Dir = config.CompressHome(pane.Dir)
+				continue
+			}
+			if rel, _ := filepath.Rel(windowDir, pane.Dir); rel == "." {
+				pane.Dir = ""
+			} else {
+				pane.Dir = rel
+			}
+		}
+	}
+}
+
+// paneDirs returns the directories of the panes of a window, ok only if
+// they are all absolute
+func paneDirs(window config.WindowConfig) (dirs []string, ok bool) {
+	for _, pane := range window.Panes {
+		if !filepath.IsAbs(pane.Dir) {
+			return nil, false
+		}
+		dirs = append(dirs, pane.Dir)
+	}
+	return dirs, true
+}
+
+// withinDir reports whether dir is parent or one of its subdirectories
+func withinDir(parent, dir string) bool {
+	return dir == parent || strings.HasPrefix(dir, strings.TrimSuffix(parent, "/")+"/")
+}
+
+// commonDir returns the deepest directory containing all the given absolute
+// directories, "" if there are none
+func commonDir(dirs []string) string {
+	if len(dirs) == 0 {
+		return ""
+	}
+
+	common := filepath.Clean(dirs[0])
+	for _, dir := range dirs[1:] {
+		dir = filepath.Clean(dir)
+		for !withinDir(common, dir) {
+			common = filepath.Dir(common)
 		}
 	}
+	return common
+}
+
+// optionScopes holds the option values each scope is compared against
+type optionScopes struct {
+	session map[string]string
+	window  map[string]string
+	server  map[string]string
+}
+
+// optionDefaults returns the defaults exported options are compared against:
+// the global session and window options, which local ones override, and
+// tmux's built-in server options. Nothing is compared when all options are
+// kept.
+func (c *Client) optionDefaults(ctx context.Context, opts ExportOptions) (optionScopes, error) {
+	if opts.KeepDefaults {
+		return optionScopes{}, nil
+	}
+
+	session, err := c.showOptions(ctx, "-g")
+	if err != nil {
+		return optionScopes{}, err
+	}
+	window, err := c.showOptions(ctx, "-g", "-w")
+	if err != nil {
+		return optionScopes{}, err
+	}
+	server, err := c.defaultServerOptions(ctx)
+	if err != nil {
+		return optionScopes{}, err
+	}
+
+	// Options taken from the environment differ between any two servers
+	live, err := c.GetServerOptions(ctx)
+	if err != nil {
+		return optionScopes{}, err
+	}
+	for _, key := range environmentOptions {
+		server[key] = live[key]
+	}
 
-	return options, nil
+	return optionScopes{session: session, window: window, server: server}, nil
 }
 
 // getSessionEnv retrieves session environment variables
-func getSessionEnv(sessionName string) (map[string]string, error) {
+func (c *Client) getSessionEnv(ctx context.Context, sessionName string) (map[string]string, error) {
+	return c.showEnvironment(ctx, "-t", sessionName)
+}
+
+// showEnvironment runs show-environment and parses its NAME=value lines,
+// skipping variables marked for removal
+func (c *Client) showEnvironment(ctx context.Context, args ...string) (map[string]string, error) {
 	env := make(map[string]string)
 
-	cmd := exec.Command("tmux", "show-environment", "-t", sessionName)
-	output, err := cmd.Output()
+	output, err := c.run(ctx, append([]string{"show-environment"}, args...)...)
 	if err != nil {
 		return env, err
 	}
 
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "-") {
@@ -137,10 +356,102 @@ func getSessionEnv(sessionName string) (map[string]string, error) {
 	return env, nil
 }
 
-// guessLayoutName tries to map a layout string to a named layout
-// This is a best-effort approach as tmux stores layouts as complex strings
-func guessLayoutName(layoutStr string) string {
-	// Tmux layouts are complex strings, we can't reliably determine the name
-	// Just return empty for now, user can set it manually
-	return ""
+// arrangePanes orders the panes of a window the way its layout tree does
+// and infers the split that creates each pane after the first. Splits are
+// nil when the layout can't be matched to the panes.
+func arrangePanes(window WindowInfo, panes []PaneInfo) ([]PaneInfo, []layout.Split, bool) {
+	cell, err := layout.Parse(window.Layout)
+	if err != nil {
+		return panes, nil, false
+	}
+
+	cells := cell.Panes()
+	if len(cells) != len(panes) {
+		return panes, nil, false
+	}
+
+	byID := make(map[string]PaneInfo, len(panes))
+	for _, pane := range panes {
+		byID[pane.ID] = pane
+	}
+
+	ordered := make([]PaneInfo, 0, len(panes))
+	for _, c := range cells {
+		pane, ok := byID[fmt.Sprintf("%%%d", c.PaneID)]
+		if !ok {
+			return panes, nil, false
+		}
+		ordered = append(ordered, pane)
+	}
+
+	splits, exact := cell.Splits()
+	if splits == nil {
+		splits = []layout.Split{}
+	}
+	return ordered, splits, exact
+}
+
+// exportSplit returns the split direction and size of the pane created by
+// the given split. Sizes are left out when a layout is applied afterwards.
+func exportSplit(splits []layout.Split, index int, windowLayout string) (string, string) {
+	// Without a layout tree, default to vertical like the launcher does
+	if index >= len(splits) {
+		return "vertical", ""
+	}
+
+	split := splits[index]
+	direction := "vertical"
+	if split.Kind == layout.LeftRight {
+		direction = "horizontal"
+	}
+
+	// Even splits are tmux's default
+	if windowLayout != "" || split.Even {
+		return direction, ""
+	}
+	return direction, fmt.Sprintf("%d%%", split.Percent)
+}
+
+// exportLayout returns the layout to record for a window: the name hive
+// applied or the preset its panes were rearranged into, nothing if the pane
+// splits rebuild it, or else the window's exact tmux layout string
+func exportLayout(window WindowInfo, paneCount int, splitsExact bool) string {
+	// Windows laid out by hive remember the layout they were given, unless
+	// their panes were rearranged since
+	if window.HiveLayout != "" {
+		if keptLayout(window) {
+			return window.HiveLayout
+		}
+		// Name the preset they were rearranged into, if any
+		if live, err := layout.Parse(window.Layout); err == nil {
+			for _, name := range layout.Presets {
+				if preset, _ := layout.Preset(name, paneCount); live.SameShape(preset) {
+					return name
+				}
+			}
+		}
+	}
+
+	// A single pane fills the window whatever the layout
+	if paneCount < 2 || splitsExact || !layout.IsLayout(window.Layout) {
+		return ""
+	}
+	return window.Layout
+}
+
+// keptLayout reports whether the panes of a window are still arranged the
+// way its hive layout puts them. Sizes don't count, since tmux resizes the
+// panes with the window.
+func keptLayout(window WindowInfo) bool {
+	live, err := layout.Parse(window.Layout)
+	if err != nil {
+		return true
+	}
+
+	applied, ok := layout.Preset(window.HiveLayout, live.PaneCount())
+	if layout.IsLayout(window.HiveLayout) {
+		applied, err = layout.Parse(window.HiveLayout)
+		ok = err == nil
+	}
+	return !ok || live.SameShape(applied)
 }
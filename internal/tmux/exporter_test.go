@@ -0,0 +1,429 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/layout"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+	cfg := launchTestConfig()
+
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	if changes := config.Diff(cfg, exported); len(changes) != 0 {
+		t.Errorf("exported config differs from launched config:")
+		for _, change := range changes {
+			t.Errorf("  %s", change)
+		}
+	}
+
+	api := exported.Windows[1]
+	if api.Panes[1].Split != "horizontal" || api.Panes[2].Split != "vertical" {
+		t.Errorf("api pane splits = %q, %q, want horizontal, vertical", api.Panes[1].Split, api.Panes[2].Split)
+	}
+	if api.Layout != "" {
+		t.Errorf("api layout = %q, want none since the splits rebuild it", api.Layout)
+	}
+}
+
+func TestExportInfersSplits(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := &config.Config{
+		Session: config.SessionConfig{Name: "sized"},
+		Windows: []config.WindowConfig{{
+			Name: "main",
+			Panes: []config.PaneConfig{
+				{Cmd: "nvim"},
+				{Cmd: "htop", Split: "horizontal", Size: "30%"},
+				{Split: "vertical", Size: "10"},
+				{Split: "vertical"},
+			},
+		}},
+	}
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	exported, err := client.ExportSession(ctx, "sized", ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	window := exported.Windows[0]
+	if window.Layout != "" {
+		t.Errorf("Layout = %q, want none", window.Layout)
+	}
+
+	want := []struct{ split, size string }{
+		{"", ""},
+		{"horizontal", "30%"},
+		{"vertical", "42%"},
+		{"vertical", ""},
+	}
+	for i, pane := range window.Panes {
+		if pane.Split != want[i].split || pane.Size != want[i].size {
+			t.Errorf("pane %d split = %q size = %q, want %q size %q", i, pane.Split, pane.Size, want[i].split, want[i].size)
+		}
+	}
+
+	// Relaunching the export reproduces the arrangement
+	exported.Session.Name = "copy"
+	if err := client.Launch(ctx, exported, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() of export error = %v", err)
+	}
+	original := server.session("sized").windows[0].root.String()
+	if copied := server.session("copy").windows[0].root.String(); !sameArrangement(original, copied) {
+		t.Errorf("relaunched layout = %s, want %s", copied, original)
+	}
+}
+
+func TestExportUnreproducibleSplits(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	cfg.Windows[1].Layout = ""
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Split the first pane after the others, which hive never does
+	first := server.session("test").windows[1].panes[0].id
+	if _, err := server.Execute(ctx, []string{"split-window", "-v", "-t", fmt.Sprintf("%%%d", first)}); err != nil {
+		t.Fatalf("split-window error = %v", err)
+	}
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	window := exported.Windows[1]
+	if want := server.session("test").windows[1].root.String(); window.Layout != want {
+		t.Errorf("Layout = %q, want the exact layout %q", window.Layout, want)
+	}
+	if len(window.Panes) != 4 {
+		t.Fatalf("exported %d panes, want 4", len(window.Panes))
+	}
+	if err := config.Validate(exported); err != nil {
+		t.Errorf("exported config is invalid: %v", err)
+	}
+}
+
+// sameArrangement compares two layout strings ignoring pane IDs
+func sameArrangement(a, b string) bool {
+	cellA, errA := layout.Parse(a)
+	cellB, errB := layout.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	for _, cell := range append(cellA.Panes(), cellB.Panes()...) {
+		cell.PaneID = -1
+	}
+	return cellA.String() == cellB.String()
+}
+
+func TestExportCurrentSession(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	if _, err := client.Export(ctx, ExportOptions{}); err == nil {
+		t.Error("Export() should fail outside of a tmux session")
+	}
+
+	server.current = "test"
+	exported, err := client.Export(ctx, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if exported.Session.Name != "test" {
+		t.Errorf("Session.Name = %q, want %q", exported.Session.Name, "test")
+	}
+}
+
+func TestExportMissingSession(t *testing.T) {
+	client := newFakeServer().client()
+
+	if _, err := client.ExportSession(context.Background(), "nope", ExportOptions{}); err == nil {
+		t.Error("ExportSession() should fail for a missing session")
+	}
+}
+
+func TestExportOptionsError(t *testing.T) {
+	server := newFakeServer()
+	if err := server.client().Launch(context.Background(), launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Window options that can't be read would be dropped from the export
+	client := &Client{Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+		if strings.HasPrefix(strings.Join(args, " "), "show-options -w ") {
+			return nil, fmt.Errorf("server exited unexpectedly")
+		}
+		return server.Execute(ctx, args)
+	})}
+
+	if _, err := client.ExportSession(context.Background(), "test", ExportOptions{}); err == nil {
+		t.Error("ExportSession() should fail when window options can't be read")
+	}
+}
+
+func TestExportEnvError(t *testing.T) {
+	server := newFakeServer()
+	if err := server.client().Launch(context.Background(), launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Without the global environment every inherited variable would be exported
+	for _, query := range []string{"show-environment -t ", "show-environment -g"} {
+		client := &Client{Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			if strings.HasPrefix(strings.Join(args, " "), query) {
+				return nil, fmt.Errorf("server exited unexpectedly")
+			}
+			return server.Execute(ctx, args)
+		})}
+
+		if _, err := client.ExportSession(context.Background(), "test", ExportOptions{}); err == nil {
+			t.Errorf("ExportSession() should fail when %q fails", query)
+		}
+	}
+}
+
+func TestExportRearrangedLayout(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	cfg.Windows[1].Layout = "main-horizontal"
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Someone lays the panes out side by side by hand
+	server.session("test").windows[1].arrange("even-horizontal")
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{KeepDefaults: true, KeepEnv: true})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+	if got := exported.Windows[1].Layout; got != "even-horizontal" {
+		t.Errorf("rearranged window layout = %q, want even-horizontal", got)
+	}
+	changes := config.Diff(cfg, exported)
+	if len(changes) != 1 || changes[0].Path != "windows[api].layout" {
+		t.Errorf("Diff() = %v, want the layout of api", changes)
+	}
+
+	// Sync lays it out again
+	plan, err := client.PlanSync(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+	if err := plan.Apply(ctx, false); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	exported, err = client.ExportSession(ctx, "test", ExportOptions{KeepDefaults: true, KeepEnv: true})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+	if changes := config.Diff(cfg, exported); len(changes) != 0 {
+		t.Errorf("Diff() after sync = %v", changes)
+	}
+}
+
+func TestExportCustomLayout(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	cfg.Windows[1].Layout = ""
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Panes arranged by hand, without hive recording a layout
+	custom := "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}"
+	server.session("test").windows[1].layout = custom
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	if got := exported.Windows[0].Layout; got != "main-vertical" {
+		t.Errorf("hive laid out window layout = %q, want main-vertical", got)
+	}
+	if got := exported.Windows[1].Layout; got != custom {
+		t.Errorf("custom window layout = %q, want %q", got, custom)
+	}
+	if err := config.Validate(exported); err != nil {
+		t.Errorf("exported config is invalid: %v", err)
+	}
+}
+
+func TestRelativizeDirs(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+
+	tests := []struct {
+		name        string
+		panes       [][]string
+		baseDir     string
+		wantBase    string
+		wantWindows []string
+		wantPanes   [][]string
+		unknown     bool
+	}{
+		{
+			name:        "project under home",
+			panes:       [][]string{{"/home/alice/src/proj", "/home/alice/src/proj"}, {"/home/alice/src/proj/api", "/home/alice/src/proj/api/cmd"}},
+			wantBase:    "~/src/proj",
+			wantWindows: []string{"", "api"},
+			wantPanes:   [][]string{{"", ""}, {"", "cmd"}},
+		},
+		{
+			name:        "window spanning directories",
+			panes:       [][]string{{"/srv/app/web", "/srv/app/api"}},
+			wantBase:    "/srv/app",
+			wantWindows: []string{""},
+			wantPanes:   [][]string{{"web", "api"}},
+		},
+		{
+			name:        "nothing in common",
+			panes:       [][]string{{"/home/alice/notes"}, {"/var/log", "/tmp"}},
+			wantBase:    "",
+			wantWindows: []string{"~/notes", ""},
+			wantPanes:   [][]string{{""}, {"/var/log", "/tmp"}},
+		},
+		{
+			name:        "given base directory",
+			panes:       [][]string{{"/home/alice/src/proj"}, {"/home/alice/src/proj/api"}, {"/var/log"}},
+			baseDir:     "~/src",
+			wantBase:    "~/src",
+			wantWindows: []string{"proj", "proj/api", "/var/log"},
+			wantPanes:   [][]string{{""}, {""}, {""}},
+		},
+		{
+			name:        "absolute directories",
+			panes:       [][]string{{"/home/alice/src/proj", "/home/alice/src/proj/api"}},
+			baseDir:     "/",
+			wantBase:    "",
+			wantWindows: []string{"~/src/proj"},
+			wantPanes:   [][]string{{"", "api"}},
+		},
+		{
+			name:        "unknown directory",
+			panes:       [][]string{{"/srv/app", ""}, {"/srv/app/api"}},
+			wantBase:    "/srv/app/api",
+			wantWindows: []string{"", ""},
+			wantPanes:   [][]string{{"/srv/app", ""}, {""}},
+			unknown:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Session: config.SessionConfig{Name: "test"}}
+			for i, dirs := range tt.panes {
+				window := config.WindowConfig{Name: fmt.Sprintf("w%d", i)}
+				for _, dir := range dirs {
+					window.Panes = append(window.Panes, config.PaneConfig{Dir: dir})
+				}
+				cfg.Windows = append(cfg.Windows, window)
+			}
+			before := *cfg
+			before.Windows = nil
+			for _, window := range cfg.Windows {
+				window.Panes = append([]config.PaneConfig(nil), window.Panes...)
+				before.Windows = append(before.Windows, window)
+			}
+
+			relativizeDirs(cfg, tt.baseDir)
+
+			if cfg.Session.BaseDir != tt.wantBase {
+				t.Errorf("base_dir = %q, want %q", cfg.Session.BaseDir, tt.wantBase)
+			}
+			for i, window := range cfg.Windows {
+				if window.Dir != tt.wantWindows[i] {
+					t.Errorf("windows[%d].dir = %q, want %q", i, window.Dir, tt.wantWindows[i])
+				}
+				for j, pane := range window.Panes {
+					if pane.Dir != tt.wantPanes[i][j] {
+						t.Errorf("windows[%d].panes[%d].dir = %q, want %q", i, j, pane.Dir, tt.wantPanes[i][j])
+					}
+				}
+			}
+
+			// Panes start in the same directories as before, except those
+			// whose directory was unknown
+			if changes := config.Diff(&before, cfg); len(changes) != 0 && !tt.unknown {
+				t.Errorf("Diff() = %v", changes)
+			}
+		})
+	}
+}
+
+func TestExportSelectedWindows(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	windows, err := client.ListWindows(ctx, "test")
+	if err != nil {
+		t.Fatalf("ListWindows() error = %v", err)
+	}
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{
+		Windows: map[string]WindowCapture{windows[1].Index: {Commands: true}},
+	})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	if len(exported.Windows) != 1 || exported.Windows[0].Name != "api" {
+		t.Fatalf("exported windows = %+v, want only api", exported.Windows)
+	}
+	for i, pane := range exported.Windows[0].Panes {
+		if pane.Dir != "" {
+			t.Errorf("pane %d dir = %q, want none", i, pane.Dir)
+		}
+		// Without a process tree only the program name is known
+		if want := strings.Fields(cfg.Windows[1].Panes[i].Cmd)[0]; pane.Cmd != want {
+			t.Errorf("pane %d cmd = %q, want %q", i, pane.Cmd, want)
+		}
+	}
+	if exported.Session.BaseDir != "" {
+		t.Errorf("base_dir = %q, want none without directories", exported.Session.BaseDir)
+	}
+}
@@ -0,0 +1,923 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/layout"
+)
+
+// fakeServer is an in-memory model of a tmux server. It understands the
+// subset of tmux commands hive issues, so the launcher and exporter can be
+// tested without a real tmux.
+type fakeServer struct {
+	sessions   []*fakeSession
+	current    string // session reported by display-message
+	nextPaneID int
+	calls      [][]string
+	version    string // reported by -V
+	maxPanes   int    // panes per window before splits fail, unlimited if 0
+
+	serverOptions map[string]string
+	globalOptions map[string]string // global session options, set with -g
+	globalWindow  map[string]string // global window options, set with -gw
+	globalEnv     map[string]string
+	others        map[string]*fakeServer // servers on other sockets, by -L name or -S path
+
+	// beforeCall runs ahead of every invocation, to let tests change the
+	// server under a running operation
+	beforeCall func(args []string)
+}
+
+type fakeSession struct {
+	name     string
+	options  map[string]string
+	env      map[string]string
+	windows  []*fakeWindow
+	active   int
+	attached int // clients reported as session_attached
+}
+
+type fakeWindow struct {
+	index   int
+	name    string
+	layout  string       // last layout given to select-layout
+	root    *layout.Cell // pane arrangement reported as window_layout
+	options map[string]string
+	panes   []*fakePane
+	active  int
+}
+
+type fakePane struct {
+	id      int
+	pid     int // reported as pane_pid when set
+	dir     string
+	command string
+	sent    []string
+	history string // printed by capture-pane
+	start   string // shell command given to respawn-pane
+}
+
+// fakeShell is the command every new pane starts with
+const fakeShell = "bash"
+
+// fakeSocket is the socket path reported by the fake server
+const fakeSocket = "/tmp/tmux-1000/default"
+
+// fakeFlags lists, per command, the flags that take a value
+var fakeFlags = map[string]string{
+	"has-session":      "t",
+	"new-session":      "scne",
+	"kill-session":     "t",
+	"list-sessions":    "F",
+	"start-server":     "",
+	"kill-server":      "",
+	"set-option":       "t",
+	"show-options":     "t",
+	"set-environment":  "t",
+	"show-environment": "t",
+	"display-message":  "tF",
+	"new-window":       "tncF",
+	"rename-window":    "t",
+	"select-window":    "t",
+	"select-layout":    "t",
+	"kill-window":      "t",
+	"list-windows":     "tF",
+	"split-window":     "tcFlp",
+	"send-keys":        "t",
+	"kill-pane":        "t",
+	"list-panes":       "tF",
+	"capture-pane":     "tSE",
+	"respawn-pane":     "tc",
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		version:       "3.4",
+		serverOptions: map[string]string{"buffer-limit": "50", "editor": "/usr/bin/vi", "escape-time": "500"},
+		globalOptions: map[string]string{"base-index": "0", "mouse": "off", "status": "on"},
+		globalWindow:  map[string]string{"mode-keys": "emacs"},
+		globalEnv:     map[string]string{"HOME": "/home/dev", "PATH": "/usr/bin:/bin"},
+		others:        map[string]*fakeServer{},
+	}
+}
+
+// client returns a Client wired to the fake server
+func (f *fakeServer) client() *Client {
+	return &Client{Executor: f}
+}
+
+// Execute implements Executor
+// Commands chained with ";" run in order until one fails, like in tmux
+func (f *fakeServer) Execute(ctx context.Context, args []string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.calls = append(f.calls, args)
+	if f.beforeCall != nil {
+		f.beforeCall(args)
+	}
+
+	if len(args) == 1 && args[0] == "-V" {
+		return []byte("tmux " + f.version + "\n"), nil
+	}
+
+	// Servers on other sockets are separate fakes, started without a config
+	if len(args) >= 2 && (args[0] == "-L" || args[0] == "-S") {
+		other, ok := f.others[args[1]]
+		if !ok {
+			other = newFakeServer()
+			f.others[args[1]] = other
+		}
+		rest := args[2:]
+		if len(rest) >= 2 && rest[0] == "-f" {
+			rest = rest[2:]
+		}
+		return other.Execute(ctx, rest)
+	}
+
+	started := false
+	var output strings.Builder
+	for _, command := range splitFakeCommands(args) {
+		if len(command) == 0 {
+			return nil, fmt.Errorf("no command given")
+		}
+
+		spec, ok := fakeFlags[command[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown command: %s", command[0])
+		}
+		flags, positional := parseFakeArgs(command[1:], spec)
+
+		// Like tmux, the server only runs while it has sessions, or for the
+		// rest of the commands after start-server
+		if command[0] == "start-server" {
+			started = true
+			continue
+		}
+		if command[0] == "kill-server" && (started || len(f.sessions) > 0) {
+			f.sessions = nil
+			started = false
+			continue
+		}
+		if len(f.sessions) == 0 && !started && command[0] != "new-session" {
+			return []byte(output.String()), newCommandError(args, 1, "no server running on "+fakeSocket)
+		}
+
+		result, err := f.dispatch(command[0], flags, positional)
+		if err != nil {
+			return []byte(output.String()), newCommandError(args, 1, err.Error())
+		}
+		if result != "" {
+			output.WriteString(result + "\n")
+		}
+	}
+
+	return []byte(output.String()), nil
+}
+
+// splitFakeCommands splits an argument list on ";" separators and removes
+// the escaping of literal trailing semicolons
+func splitFakeCommands(args []string) [][]string {
+	commands := [][]string{{}}
+	for _, arg := range args {
+		if arg == ";" {
+			commands = append(commands, []string{})
+			continue
+		}
+		if strings.HasSuffix(arg, "\\;") {
+			arg = strings.TrimSuffix(arg, "\\;") + ";"
+		}
+		commands[len(commands)-1] = append(commands[len(commands)-1], arg)
+	}
+	return commands
+}
+
+func (f *fakeServer) dispatch(command string, flags map[string]string, positional []string) (string, error) {
+	switch command {
+	case "has-session":
+		_, err := f.findSession(flags["t"])
+		return "", err
+
+	case "new-session":
+		name := flags["s"]
+		if _, err := f.findSession("=" + name); err == nil {
+			return "", fmt.Errorf("duplicate session: %s", name)
+		}
+		session := &fakeSession{
+			name:    name,
+			options: map[string]string{},
+			env:     map[string]string{},
+		}
+		if flags["e"] != "" {
+			for _, assignment := range strings.Split(flags["e"], "\n") {
+				key, value, _ := strings.Cut(assignment, "=")
+				session.env[key] = value
+			}
+		}
+		f.sessions = append(f.sessions, session)
+		window := session.addWindow(flags["n"], f.newPane(flags["c"]))
+		if window.name == "" {
+			window.name = fakeShell
+		}
+		return "", nil
+
+	case "kill-session":
+		session, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		f.removeSession(session)
+		return "", nil
+
+	case "list-sessions":
+		var lines []string
+		for _, session := range f.sessions {
+			window := session.windows[session.active]
+			lines = append(lines, expandFakeFormat(flags["F"], f.vars(session, window, window.panes[window.active])))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "set-option":
+		if len(positional) != 2 {
+			return "", fmt.Errorf("set-option: expected option and value")
+		}
+		options, err := f.optionsFor(flags)
+		if err != nil {
+			return "", err
+		}
+		options[positional[0]] = positional[1]
+		return "", nil
+
+	case "show-options":
+		options, err := f.optionsFor(flags)
+		if err != nil {
+			return "", err
+		}
+		var lines []string
+		for _, key := range sortedKeys(options) {
+			if len(positional) > 0 && positional[0] != key {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", key, quoteFakeOption(options[key])))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "set-environment":
+		session, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if len(positional) != 2 {
+			return "", fmt.Errorf("set-environment: expected name and value")
+		}
+		session.env[positional[0]] = positional[1]
+		return "", nil
+
+	case "show-environment":
+		env := f.globalEnv
+		if flags["g"] == "" {
+			session, err := f.findSession(flags["t"])
+			if err != nil {
+				return "", err
+			}
+			env = session.env
+		}
+		var lines []string
+		for _, key := range sortedKeys(env) {
+			lines = append(lines, fmt.Sprintf("%s=%s", key, env[key]))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "display-message":
+		target := flags["t"]
+		if target == "" {
+			target = f.current
+		}
+		session, err := f.findSession(target)
+		if err != nil {
+			return "", fmt.Errorf("no current client")
+		}
+		window := session.windows[session.active]
+		format := flags["F"]
+		if len(positional) > 0 {
+			format = positional[0]
+		}
+		return expandFakeFormat(format, f.vars(session, window, window.panes[window.active])), nil
+
+	case "new-window":
+		session, err := f.findSession(sessionPart(flags["t"]))
+		if err != nil {
+			return "", err
+		}
+		window := session.addWindow(flags["n"], f.newPane(flags["c"]))
+		if flags["d"] == "" {
+			session.active = len(session.windows) - 1
+		}
+		if flags["P"] == "" {
+			return "", nil
+		}
+		return expandFakeFormat(flags["F"], f.vars(session, window, window.panes[0])), nil
+
+	case "rename-window":
+		_, window, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if len(positional) != 1 {
+			return "", fmt.Errorf("rename-window: expected a name")
+		}
+		window.name = positional[0]
+		return "", nil
+
+	case "select-window":
+		session, window, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		for i, candidate := range session.windows {
+			if candidate == window {
+				session.active = i
+			}
+		}
+		return "", nil
+
+	case "select-layout":
+		_, window, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if len(positional) == 1 {
+			window.layout = positional[0]
+			window.arrange(positional[0])
+		}
+		return "", nil
+
+	case "kill-window":
+		session, window, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		session.removeWindow(window)
+		if len(session.windows) == 0 {
+			f.removeSession(session)
+		}
+		return "", nil
+
+	case "list-windows":
+		session, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		var lines []string
+		for _, window := range session.windows {
+			lines = append(lines, expandFakeFormat(flags["F"], f.vars(session, window, window.panes[window.active])))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "split-window":
+		session, window, target, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if f.maxPanes > 0 && len(window.panes) >= f.maxPanes {
+			return "", fmt.Errorf("no space for new pane")
+		}
+		dir := flags["c"]
+		if dir == "" {
+			dir = target.dir
+		}
+		pane := f.newPane(dir)
+		kind := layout.TopBottom
+		if flags["h"] != "" {
+			kind = layout.LeftRight
+		}
+		if err := window.split(target, pane, kind, flags["l"], flags["p"]); err != nil {
+			return "", err
+		}
+		for i, candidate := range window.panes {
+			if candidate == target {
+				window.panes = append(window.panes[:i+1], append([]*fakePane{pane}, window.panes[i+1:]...)...)
+				window.active = i + 1
+				break
+			}
+		}
+		if flags["P"] == "" {
+			return "", nil
+		}
+		return expandFakeFormat(flags["F"], f.vars(session, window, pane)), nil
+
+	case "send-keys":
+		_, _, pane, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		pane.sendKeys(positional)
+		return "", nil
+
+	case "kill-pane":
+		session, window, pane, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		window.removePane(pane)
+		if len(window.panes) == 0 {
+			session.removeWindow(window)
+		}
+		return "", nil
+
+	case "capture-pane":
+		_, _, pane, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		return pane.history, nil
+
+	case "respawn-pane":
+		_, _, pane, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if flags["k"] == "" {
+			return "", fmt.Errorf("pane %%%d still active", pane.id)
+		}
+		if flags["c"] != "" {
+			pane.dir = flags["c"]
+		}
+		if len(positional) > 0 {
+			pane.start = positional[0]
+		}
+		pane.sent = nil
+		return "", nil
+
+	case "list-panes":
+		session, window, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		windows := []*fakeWindow{window}
+		if flags["s"] != "" {
+			windows = session.windows
+		}
+		var lines []string
+		for _, window := range windows {
+			for _, pane := range window.panes {
+				lines = append(lines, expandFakeFormat(flags["F"], f.vars(session, window, pane)))
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	return "", fmt.Errorf("unknown command: %s", command)
+}
+
+func (f *fakeServer) newPane(dir string) *fakePane {
+	pane := &fakePane{id: f.nextPaneID, dir: dir, command: fakeShell}
+	f.nextPaneID++
+	return pane
+}
+
+// findSession looks up a session by exact name ("=name") or unique prefix,
+// the way tmux resolves session targets
+func (f *fakeServer) findSession(target string) (*fakeSession, error) {
+	if strings.HasPrefix(target, "=") {
+		name := strings.TrimPrefix(target, "=")
+		for _, session := range f.sessions {
+			if session.name == name {
+				return session, nil
+			}
+		}
+		return nil, fmt.Errorf("can't find session: %s", name)
+	}
+
+	for _, session := range f.sessions {
+		if session.name == target {
+			return session, nil
+		}
+	}
+
+	var matches []*fakeSession
+	for _, session := range f.sessions {
+		if target != "" && strings.HasPrefix(session.name, target) {
+			matches = append(matches, session)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("can't find session: %s", target)
+}
+
+// resolve turns a target ("%3", "session", "session:", "session:2",
+// "session:2.1") into the session, window and pane it names
+func (f *fakeServer) resolve(target string) (*fakeSession, *fakeWindow, *fakePane, error) {
+	if strings.HasPrefix(target, "%") {
+		for _, session := range f.sessions {
+			for _, window := range session.windows {
+				for _, pane := range window.panes {
+					if fmt.Sprintf("%%%d", pane.id) == target {
+						return session, window, pane, nil
+					}
+				}
+			}
+		}
+		return nil, nil, nil, fmt.Errorf("can't find pane: %s", target)
+	}
+
+	session, err := f.findSession(sessionPart(target))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	window := session.windows[session.active]
+	rest := ""
+	if i := strings.Index(target, ":"); i >= 0 {
+		rest = target[i+1:]
+	}
+	windowPart, panePart, _ := strings.Cut(rest, ".")
+	if windowPart == "^" {
+		window = session.windows[0]
+		for _, candidate := range session.windows {
+			if candidate.index < window.index {
+				window = candidate
+			}
+		}
+	} else if windowPart != "" {
+		window = nil
+		for _, candidate := range session.windows {
+			if strconv.Itoa(candidate.index) == windowPart || candidate.name == windowPart {
+				window = candidate
+				break
+			}
+		}
+		if window == nil {
+			return nil, nil, nil, fmt.Errorf("can't find window: %s", windowPart)
+		}
+	}
+
+	pane := window.panes[window.active]
+	if panePart != "" {
+		i, err := strconv.Atoi(panePart)
+		if err != nil || i < 0 || i >= len(window.panes) {
+			return nil, nil, nil, fmt.Errorf("can't find pane: %s", panePart)
+		}
+		pane = window.panes[i]
+	}
+
+	return session, window, pane, nil
+}
+
+// optionsFor returns the option table a set-option/show-options call targets
+func (f *fakeServer) optionsFor(flags map[string]string) (map[string]string, error) {
+	switch {
+	case flags["s"] != "":
+		return f.serverOptions, nil
+	case flags["g"] != "" && flags["w"] != "":
+		return f.globalWindow, nil
+	case flags["g"] != "":
+		return f.globalOptions, nil
+	}
+
+	if flags["w"] != "" {
+		_, window, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return nil, err
+		}
+		return window.options, nil
+	}
+
+	session, err := f.findSession(sessionPart(flags["t"]))
+	if err != nil {
+		return nil, err
+	}
+	return session.options, nil
+}
+
+func (f *fakeServer) removeSession(session *fakeSession) {
+	for i, candidate := range f.sessions {
+		if candidate == session {
+			f.sessions = append(f.sessions[:i], f.sessions[i+1:]...)
+			return
+		}
+	}
+}
+
+// vars returns the format variables for a pane in context
+func (f *fakeServer) vars(session *fakeSession, window *fakeWindow, pane *fakePane) map[string]string {
+	// Layout strings aren't applied to the tree but reported as given
+	windowLayout := window.root.String()
+	if layout.IsLayout(window.layout) {
+		windowLayout = window.layout
+	}
+
+	vars := map[string]string{
+		"session_name":         session.name,
+		"session_windows":      strconv.Itoa(len(session.windows)),
+		"session_attached":     strconv.Itoa(session.attached),
+		"window_index":         strconv.Itoa(window.index),
+		"window_name":          window.name,
+		"window_layout":        windowLayout,
+		"pane_id":              fmt.Sprintf("%%%d", pane.id),
+		"pane_current_path":    pane.dir,
+		"pane_current_command": pane.command,
+	}
+	if pane.pid > 0 {
+		vars["pane_pid"] = strconv.Itoa(pane.pid)
+	}
+	for key, value := range session.options {
+		if strings.HasPrefix(key, "@") {
+			vars[key] = value
+		}
+	}
+	for key, value := range window.options {
+		if strings.HasPrefix(key, "@") {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+func (s *fakeSession) addWindow(name string, pane *fakePane) *fakeWindow {
+	index := 0
+	if base, err := strconv.Atoi(s.options["base-index"]); err == nil {
+		index = base
+	}
+	for _, window := range s.windows {
+		if window.index >= index {
+			index = window.index + 1
+		}
+	}
+
+	window := &fakeWindow{
+		index:   index,
+		name:    name,
+		root:    &layout.Cell{Kind: layout.Pane, Width: 80, Height: 24, PaneID: pane.id},
+		options: map[string]string{},
+		panes:   []*fakePane{pane},
+	}
+	s.windows = append(s.windows, window)
+	return window
+}
+
+func (s *fakeSession) removeWindow(window *fakeWindow) {
+	for i, candidate := range s.windows {
+		if candidate == window {
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			break
+		}
+	}
+	if s.active >= len(s.windows) {
+		s.active = 0
+	}
+}
+
+func (w *fakeWindow) removePane(pane *fakePane) {
+	for i, candidate := range w.panes {
+		if candidate == pane {
+			w.panes = append(w.panes[:i], w.panes[i+1:]...)
+			break
+		}
+	}
+	if w.active >= len(w.panes) {
+		w.active = 0
+	}
+
+	// The neighbouring cell takes over the space of the pane, and a split
+	// left with a single cell is replaced by it
+	cell, parent := findFakeCell(w.root, nil, pane.id)
+	if parent == nil {
+		return
+	}
+	i := slices.Index(parent.Children, cell)
+	parent.Children = slices.Delete(parent.Children, i, i+1)
+	growFakeCell(parent.Children[max(i-1, 0)], parent.Kind, fakeSpan(cell, parent.Kind)+1)
+	if len(parent.Children) == 1 {
+		*parent = *parent.Children[0]
+	}
+	placeFakeCell(w.root, 0, 0)
+}
+
+// split divides the cell of the target pane the way tmux does: splitting
+// in the direction of the parent adds a sibling, otherwise the cell becomes
+// a split of the target and the new pane. The new pane gets half of the
+// target unless a size is given.
+func (w *fakeWindow) split(target, pane *fakePane, kind layout.Kind, length, percent string) error {
+	cell, parent := findFakeCell(w.root, nil, target.id)
+	if cell == nil {
+		return fmt.Errorf("can't find pane: %%%d", target.id)
+	}
+
+	span := fakeSpan(cell, kind)
+	size := (span+1)/2 - 1
+	if value, ok := strings.CutSuffix(length, "%"); ok {
+		percent = value
+	} else if length != "" {
+		size, _ = strconv.Atoi(length)
+	}
+	if percent != "" {
+		value, _ := strconv.Atoi(percent)
+		size = span * value / 100
+	}
+	if size < 1 || span-size-1 < 1 {
+		return fmt.Errorf("no space for new pane")
+	}
+
+	added := &layout.Cell{Kind: layout.Pane, Width: cell.Width, Height: cell.Height, PaneID: pane.id}
+	if parent != nil && parent.Kind == kind {
+		i := slices.Index(parent.Children, cell)
+		parent.Children = slices.Insert(parent.Children, i+1, added)
+	} else {
+		old := *cell
+		*cell = layout.Cell{Kind: kind, Width: cell.Width, Height: cell.Height, PaneID: -1, Children: []*layout.Cell{&old, added}}
+		cell = &old
+	}
+
+	growFakeCell(cell, kind, -size-1)
+	growFakeCell(added, kind, size-span)
+	placeFakeCell(w.root, 0, 0)
+	return nil
+}
+
+// arrange lays the panes out with a preset the way tmux does, sharing every
+// split evenly between its children
+func (w *fakeWindow) arrange(name string) {
+	preset, ok := layout.Preset(name, len(w.panes))
+	if !ok {
+		return
+	}
+	for i, cell := range preset.Panes() {
+		cell.PaneID = w.panes[i].id
+	}
+	sizeFakeCell(preset, w.root.Width, w.root.Height)
+	placeFakeCell(preset, 0, 0)
+	w.root = preset
+}
+
+// sizeFakeCell gives a cell its size and shares it evenly between its
+// children, the last one taking what is left
+func sizeFakeCell(cell *layout.Cell, width, height int) {
+	cell.Width, cell.Height = width, height
+	left := fakeSpan(cell, cell.Kind) + 1
+	for i, child := range cell.Children {
+		span := left/(len(cell.Children)-i) - 1
+		left -= span + 1
+		if cell.Kind == layout.LeftRight {
+			sizeFakeCell(child, span, height)
+		} else {
+			sizeFakeCell(child, width, span)
+		}
+	}
+}
+
+// findFakeCell returns the cell of a pane and the split holding it
+func findFakeCell(cell, parent *layout.Cell, paneID int) (*layout.Cell, *layout.Cell) {
+	if cell.Kind == layout.Pane {
+		if cell.PaneID == paneID {
+			return cell, parent
+		}
+		return nil, nil
+	}
+	for _, child := range cell.Children {
+		if found, foundParent := findFakeCell(child, cell, paneID); found != nil {
+			return found, foundParent
+		}
+	}
+	return nil, nil
+}
+
+// growFakeCell resizes a cell along the direction of kind: the last child
+// of a split in that direction absorbs the change, children across it all
+// follow it
+func growFakeCell(cell *layout.Cell, kind layout.Kind, delta int) {
+	if kind == layout.LeftRight {
+		cell.Width += delta
+	} else {
+		cell.Height += delta
+	}
+	for i, child := range cell.Children {
+		if cell.Kind != kind || i == len(cell.Children)-1 {
+			growFakeCell(child, kind, delta)
+		}
+	}
+}
+
+// placeFakeCell recomputes the offsets of a cell and its children
+func placeFakeCell(cell *layout.Cell, x, y int) {
+	cell.X, cell.Y = x, y
+	for _, child := range cell.Children {
+		placeFakeCell(child, x, y)
+		if cell.Kind == layout.LeftRight {
+			x += child.Width + 1
+		} else {
+			y += child.Height + 1
+		}
+	}
+}
+
+// fakeSpan returns the size of a cell along the direction of kind
+func fakeSpan(cell *layout.Cell, kind layout.Kind) int {
+	if kind == layout.LeftRight {
+		return cell.Width
+	}
+	return cell.Height
+}
+
+// sendKeys simulates typing into the pane's shell: "cd" changes the pane
+// directory, anything else becomes the running command
+func (p *fakePane) sendKeys(keys []string) {
+	p.sent = append(p.sent, keys...)
+	if len(keys) < 2 || keys[len(keys)-1] != "Enter" {
+		return
+	}
+
+	line := strings.Join(keys[:len(keys)-1], " ")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	if fields[0] == "cd" && len(fields) > 1 {
+		if dir, err := strconv.Unquote(fields[1]); err == nil {
+			p.dir = dir
+		} else {
+			p.dir = fields[1]
+		}
+		return
+	}
+	p.command = fields[0]
+}
+
+// quoteFakeOption quotes an option value the way show-options does
+func quoteFakeOption(value string) string {
+	if value == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(value, " \"'\\$") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(value) + `"`
+}
+
+// sessionPart returns the session name from a target like "name:window.pane"
+func sessionPart(target string) string {
+	name, _, _ := strings.Cut(target, ":")
+	return name
+}
+
+// parseFakeArgs splits tmux arguments into flags and positional arguments.
+// Flags listed in spec take a value; all others are boolean.
+func parseFakeArgs(args []string, spec string) (map[string]string, []string) {
+	flags := make(map[string]string)
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(positional) > 0 || !strings.HasPrefix(arg, "-") || len(arg) < 2 {
+			positional = append(positional, arg)
+			continue
+		}
+		for j := 1; j < len(arg); j++ {
+			flag := string(arg[j])
+			if strings.Contains(spec, flag) {
+				value := ""
+				if j+1 < len(arg) {
+					value = arg[j+1:]
+				} else if i+1 < len(args) {
+					i++
+					value = args[i]
+				}
+				// Repeated flags such as -e accumulate, one value per line
+				if previous, ok := flags[flag]; ok {
+					value = previous + "\n" + value
+				}
+				flags[flag] = value
+				break
+			}
+			flags[flag] = "1"
+		}
+	}
+
+	return flags, positional
+}
+
+var fakeFormatPattern = regexp.MustCompile(`#\{([^}]+)\}`)
+
+// expandFakeFormat replaces #{name} references with their values
+func expandFakeFormat(format string, vars map[string]string) string {
+	return fakeFormatPattern.ReplaceAllStringFunc(format, func(ref string) string {
+		return vars[ref[2:len(ref)-1]]
+	})
+}
+
+// session returns the named session or nil
+func (f *fakeServer) session(name string) *fakeSession {
+	for _, session := range f.sessions {
+		if session.name == name {
+			return session
+		}
+	}
+	return nil
+}
@@ -1,165 +1,363 @@
 package tmux
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
-	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/arch-err/tmux-hive/internal/config"
 )
 
+// LaunchOptions controls how a session is launched
+type LaunchOptions struct {
+	// KeepPartial leaves a partially built session in place when launch
+	// fails, for debugging
+	KeepPartial bool
+
+	// ConfigPath is the absolute path of the config file. When set, the
+	// session is tagged with it, ConfigHash, Version and the launch time so
+	// that hive can find the sessions it launched.
+	ConfigPath string
+	// ConfigHash identifies the contents of the config file at launch
+	ConfigHash string
+	// Version is the version of hive launching the session
+	Version string
+}
+
+// LaunchError reports the launch plan step that failed
+type LaunchError struct {
+	Session     string
+	Step        int // index of the failed step in the launch plan
+	Total       int // number of steps in the launch plan
+	Description string
+	Err         error
+	RolledBack  bool // whether the partial session was removed
+}
+
+func (e *LaunchError) Error() string {
+	if errors.Is(e.Err, context.Canceled) {
+		return fmt.Sprintf("launch interrupted at step %d of %d (%s)", e.Step+1, e.Total, e.Description)
+	}
+	return fmt.Sprintf("failed to %s (step %d of %d): %v", e.Description, e.Step+1, e.Total, e.Err)
+}
+
+func (e *LaunchError) Unwrap() error {
+	return e.Err
+}
+
 // Launch creates a tmux session from a configuration
-func Launch(cfg *config.Config) error {
+// The whole session is built by a single tmux invocation. If any step fails
+// or ctx is cancelled, the partial session is killed unless opts.KeepPartial
+// is set, and a *LaunchError names the step.
+func (c *Client) Launch(ctx context.Context, cfg *config.Config, opts LaunchOptions) error {
 	// Check if session already exists
-	if SessionExists(cfg.Session.Name) {
-		return fmt.Errorf("session '%s' already exists. Kill it first or use a different name", cfg.Session.Name)
+	if c.SessionExists(ctx, cfg.Session.Name) {
+		return fmt.Errorf("%w: %s", ErrSessionExists, cfg.Session.Name)
 	}
 
-	// Create the session
-	if err := CreateSession(cfg.Session.Name, cfg.Session.BaseDir, cfg.Options); err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+	version, err := c.Version(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Set environment variables
-	if err := SetEnvVars(cfg.Session.Name, cfg.Env); err != nil {
-		return fmt.Errorf("failed to set environment variables: %w", err)
+	steps := LaunchSteps(cfg, version, opts, time.Now())
+	completed, err := c.runTracked(ctx, cfg.Session.Name, steps)
+	if err == nil {
+		return nil
 	}
 
-	// Get the base directory for resolving relative paths
-	baseDir := cfg.Session.BaseDir
-	if baseDir == "" {
-		baseDir = "."
+	launchErr := &LaunchError{
+		Session: cfg.Session.Name,
+		Step:    completed,
+		Total:   len(steps),
+		Err:     err,
+	}
+	if completed < len(steps) {
+		launchErr.Description = steps[completed].Description
 	}
 
-	// Track first window index for later selection
-	var firstWindowIndex string
+	// Until the session is created, a session of that name belongs to
+	// someone else
+	if completed > 0 && !opts.KeepPartial {
+		launchErr.RolledBack = c.rollback(ctx, cfg.Session.Name)
+	}
 
-	// Create windows and panes
-	for i, window := range cfg.Windows {
-		windowDir := resolveDir(baseDir, window.Dir)
-
-		var windowIndex string
-		if i == 0 {
-			// First window is automatically created, get its index
-			windows, err := ListWindows(cfg.Session.Name)
-			if err != nil {
-				return fmt.Errorf("failed to list windows: %w", err)
-			}
-			if len(windows) == 0 {
-				return fmt.Errorf("no windows found in session")
-			}
-			windowIndex = windows[0].Index
-			firstWindowIndex = windowIndex
+	return launchErr
+}
 
-			// Rename the first window
-			if err := renameWindow(cfg.Session.Name, windowIndex, window.Name); err != nil {
-				return fmt.Errorf("failed to rename first window: %w", err)
-			}
-		} else {
-			// Create additional windows
-			var err error
-			windowIndex, err = CreateWindow(cfg.Session.Name, window.Name, windowDir, window.Layout)
-			if err != nil {
-				return fmt.Errorf("failed to create window '%s': %w", window.Name, err)
-			}
-		}
+// rollback kills a partially built session and reports whether none is left
+// It runs even when ctx is already cancelled
+func (c *Client) rollback(ctx context.Context, sessionName string) bool {
+	ctx = context.WithoutCancel(ctx)
+	if !c.SessionExists(ctx, sessionName) {
+		return true
+	}
+	return c.KillSession(ctx, sessionName) == nil
+}
+
+// LaunchSteps returns every tmux command Launch runs for a config: the
+// launch plan, then the tagging of the session with its config
+func LaunchSteps(cfg *config.Config, version Version, opts LaunchOptions, launched time.Time) []Step {
+	return append(LaunchPlan(cfg, version), tagSteps(cfg.Session.Name, opts, launched)...)
+}
+
+// tagSteps returns the steps that record how hive launched a session in its
+// @hive-* options, none when opts has no config path
+func tagSteps(sessionName string, opts LaunchOptions, launched time.Time) []Step {
+	if opts.ConfigPath == "" {
+		return nil
+	}
 
-		// Create panes
-		if len(window.Panes) == 0 {
+	tags := []struct{ option, value string }{
+		{OptionConfig, opts.ConfigPath},
+		{OptionConfigHash, opts.ConfigHash},
+		{OptionVersion, opts.Version},
+		{OptionLaunched, strconv.FormatInt(launched.Unix(), 10)},
+	}
+
+	var steps []Step
+	for _, tag := range tags {
+		if tag.value == "" {
 			continue
 		}
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("record %s", tag.option),
+			Args:        []string{"set-option", "-t", sessionName, tag.option, tag.value},
+		})
+	}
+	return steps
+}
 
-		// First pane is already created with the window
-		firstPane := window.Panes[0]
-		firstPaneDir := resolveDir(windowDir, firstPane.Dir)
+// LaunchPlan compiles a configuration into the ordered tmux commands that
+// create its session on the given tmux version
+func LaunchPlan(cfg *config.Config, version Version) []Step {
+	name := cfg.Session.Name
+	// "name:" addresses the current window of the session, which is always
+	// the window created last
+	current := name + ":"
 
-		// Get the first pane ID
-		panes, err := ListPanes(cfg.Session.Name, windowIndex)
-		if err != nil {
-			return fmt.Errorf("failed to list panes: %w", err)
-		}
-		if len(panes) == 0 {
-			return fmt.Errorf("no panes found in window")
-		}
-		firstPaneID := panes[0].ID
+	// Get the base directory for resolving relative paths
+	baseDir := cfg.BaseDir()
 
-		// Change directory if needed
-		if firstPaneDir != "" && firstPaneDir != windowDir {
-			if err := SendCommand(firstPaneID, fmt.Sprintf("cd %q", firstPaneDir)); err != nil {
-				return fmt.Errorf("failed to change directory in first pane: %w", err)
-			}
-		}
+	// The session starts in the directory of the first pane
+	args := []string{"new-session", "-d", "-s", name}
+	if len(cfg.Windows) > 0 {
+		args = append(args, "-n", cfg.Windows[0].Name)
+	}
+	args = append(args, startDirArgs(firstPaneDir(cfg, baseDir))...)
 
-		// Send command to first pane
-		if firstPane.Cmd != "" {
-			if err := SendCommand(firstPaneID, firstPane.Cmd); err != nil {
-				return fmt.Errorf("failed to send command to first pane: %w", err)
-			}
+	// Passing the environment to new-session lets the first pane see it too;
+	// older servers only get set-environment, which later panes inherit
+	envOnCreate := version.Supports(FeatureNewSessionEnv)
+	if envOnCreate {
+		for _, key := range sortedKeys(cfg.Env) {
+			args = append(args, "-e", key+"="+cfg.Env[key])
 		}
+	}
 
-		// Create additional panes
-		for j := 1; j < len(window.Panes); j++ {
-			pane := window.Panes[j]
-			paneDir := resolveDir(windowDir, pane.Dir)
+	steps := []Step{{
+		Description: fmt.Sprintf("create session '%s'", name),
+		Args:        args,
+	}}
 
-			// Create the pane
-			paneID, err := CreatePane(cfg.Session.Name, windowIndex, paneDir, pane.Split)
-			if err != nil {
-				return fmt.Errorf("failed to create pane %d in window '%s': %w", j, window.Name, err)
-			}
+	// Apply server options
+	for _, key := range sortedKeys(cfg.ServerOptions) {
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("set server option %s", key),
+			Args:        []string{"set-option", "-s", key, config.FormatOptionValue(cfg.ServerOptions[key])},
+		})
+	}
 
-			// Send command if specified
-			if pane.Cmd != "" {
-				if err := SendCommand(paneID, pane.Cmd); err != nil {
-					return fmt.Errorf("failed to send command to pane: %w", err)
-				}
-			}
+	// Apply session options
+	for _, key := range sortedKeys(cfg.Options) {
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("set option %s", key),
+			Args:        []string{"set-option", "-t", name, key, config.FormatOptionValue(cfg.Options[key])},
+		})
+	}
+
+	// Set environment variables
+	for _, key := range sortedKeys(cfg.Env) {
+		if envOnCreate {
+			break
 		}
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("set environment %s", key),
+			Args:        []string{"set-environment", "-t", name, key, cfg.Env[key]},
+		})
+	}
+
+	// Create windows and panes
+	for i, window := range cfg.Windows {
+		windowDir := config.ResolveDir(baseDir, window.Dir)
 
-		// Set window layout after all panes are created
-		if window.Layout != "" {
-			if err := SetWindowLayout(cfg.Session.Name, windowIndex, window.Layout); err != nil {
-				return fmt.Errorf("failed to set window layout: %w", err)
+		if i > 0 {
+			args := []string{"new-window", "-t", name, "-n", window.Name}
+			if len(window.Panes) > 0 {
+				args = append(args, startDirArgs(config.ResolveDir(windowDir, window.Panes[0].Dir))...)
 			}
+			steps = append(steps, Step{
+				Description: fmt.Sprintf("create window '%s'", window.Name),
+				Args:        args,
+			})
 		}
+
+		steps = append(steps, windowSteps(current, windowDir, window, version)...)
 	}
 
 	// Select first window
-	if len(cfg.Windows) > 0 && firstWindowIndex != "" {
-		if err := selectWindow(cfg.Session.Name, firstWindowIndex); err != nil {
-			return fmt.Errorf("failed to select first window: %w", err)
-		}
+	if len(cfg.Windows) > 0 {
+		steps = append(steps, Step{
+			Description: "select first window",
+			Args:        []string{"select-window", "-t", name + ":^"},
+		})
 	}
 
-	return nil
+	return steps
+}
+
+// windowSteps returns the steps that populate a window whose first pane
+// already exists: the window options, the first pane command, the remaining
+// panes and the layout
+func windowSteps(target, windowDir string, window config.WindowConfig, version Version) []Step {
+	var steps []Step
+
+	for _, key := range sortedKeys(window.Options) {
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("set option %s of window '%s'", key, window.Name),
+			Args:        []string{"set-option", "-w", "-t", target, key, config.FormatOptionValue(window.Options[key])},
+		})
+	}
+
+	if len(window.Panes) > 0 && window.Panes[0].Cmd != "" {
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("start pane 0 in window '%s'", window.Name),
+			Args:        []string{"send-keys", "-t", target, window.Panes[0].Cmd, "Enter"},
+		})
+	}
+
+	for j := 1; j < len(window.Panes); j++ {
+		steps = append(steps, paneSteps(target, target, windowDir, window.Name, j, window.Panes[j], version)...)
+	}
+
+	// Set window layout after all panes are created
+	if window.Layout != "" {
+		steps = append(steps, layoutSteps(target, window.Name, window.Layout)...)
+	}
+
+	return steps
 }
 
-// resolveDir resolves a directory path relative to a base directory
-func resolveDir(baseDir, dir string) string {
-	if dir == "" {
+// paneSteps returns the steps that split a new pane off the pane at
+// splitTarget, the active pane when it is a window, and start its command.
+// The new pane becomes the active pane, so the command is sent to the window
+// target.
+func paneSteps(target, splitTarget, windowDir, windowName string, index int, pane config.PaneConfig, version Version) []Step {
+	args := []string{"split-window", "-t", splitTarget}
+
+	// Set split direction
+	if pane.Split == "horizontal" {
+		args = append(args, "-h")
+	} else {
+		// Default to vertical split
+		args = append(args, "-v")
+	}
+
+	// Set the size of the new pane
+	args = append(args, sizeArgs(pane.Size, version)...)
+
+	// Set starting directory
+	args = append(args, startDirArgs(config.ResolveDir(windowDir, pane.Dir))...)
+
+	steps := []Step{{
+		Description: fmt.Sprintf("create pane %d in window '%s'", index, windowName),
+		Args:        args,
+	}}
+
+	if pane.Cmd != "" {
+		steps = append(steps, Step{
+			Description: fmt.Sprintf("start pane %d in window '%s'", index, windowName),
+			Args:        []string{"send-keys", "-t", target, pane.Cmd, "Enter"},
+		})
+	}
+
+	return steps
+}
+
+// layoutSteps applies a layout and records its name in @hive-layout
+func layoutSteps(target, windowName, layout string) []Step {
+	return []Step{
+		{
+			Description: fmt.Sprintf("set layout of window '%s'", windowName),
+			Args:        []string{"select-layout", "-t", target, layout},
+		},
+		{
+			Description: fmt.Sprintf("record layout of window '%s'", windowName),
+			Args:        []string{"set-option", "-w", "-t", target, "@hive-layout", layout},
+		},
+	}
+}
+
+// sizeArgs returns the flags that size a new pane. Percentages are passed
+// to -l from tmux 3.1 on and to -p before.
+func sizeArgs(size string, version Version) []string {
+	if size == "" {
+		return nil
+	}
+	value, percent, err := config.ParseSize(size)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case !percent:
+		return []string{"-l", strconv.Itoa(value)}
+	case version.Supports(FeatureSplitPercent):
+		return []string{"-l", fmt.Sprintf("%d%%", value)}
+	default:
+		return []string{"-p", strconv.Itoa(value)}
+	}
+}
+
+// startDirArgs returns the -c flag for a starting directory
+// The current directory is tmux's default and needs no flag
+func startDirArgs(dir string) []string {
+	if dir == "" || dir == "." {
+		return nil
+	}
+	return []string{"-c", dir}
+}
+
+// firstPaneDir returns the starting directory of the first pane of the
+// first window
+func firstPaneDir(cfg *config.Config, baseDir string) string {
+	if len(cfg.Windows) == 0 {
 		return baseDir
 	}
-	if filepath.IsAbs(dir) {
-		return dir
+
+	window := cfg.Windows[0]
+	windowDir := config.ResolveDir(baseDir, window.Dir)
+	if len(window.Panes) == 0 {
+		return windowDir
 	}
-	return filepath.Join(baseDir, dir)
+	return config.ResolveDir(windowDir, window.Panes[0].Dir)
 }
 
 // renameWindow renames a window
-func renameWindow(sessionName, windowIndex, newName string) error {
+func (c *Client) renameWindow(ctx context.Context, sessionName, windowIndex, newName string) error {
 	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
-	cmd := exec.Command("tmux", "rename-window", "-t", target, newName)
-	if err := cmd.Run(); err != nil {
+	if _, err := c.run(ctx, "rename-window", "-t", target, newName); err != nil {
 		return fmt.Errorf("failed to rename window: %w", err)
 	}
 	return nil
 }
 
 // selectWindow selects a window
-func selectWindow(sessionName, windowIndex string) error {
+func (c *Client) selectWindow(ctx context.Context, sessionName, windowIndex string) error {
 	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
-	cmd := exec.Command("tmux", "select-window", "-t", target)
-	if err := cmd.Run(); err != nil {
+	if _, err := c.run(ctx, "select-window", "-t", target); err != nil {
 		return fmt.Errorf("failed to select window: %w", err)
 	}
 	return nil
@@ -0,0 +1,329 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+func launchTestConfig() *config.Config {
+	return &config.Config{
+		Session: config.SessionConfig{
+			Name:    "test",
+			BaseDir: "/srv/app",
+		},
+		Windows: []config.WindowConfig{
+			{
+				Name:   "editor",
+				Layout: "main-vertical",
+				Panes: []config.PaneConfig{
+					{Cmd: "nvim ."},
+					{Cmd: "", Split: "vertical"},
+				},
+			},
+			{
+				Name: "api",
+				Dir:  "api",
+				Panes: []config.PaneConfig{
+					{Cmd: "npm run dev"},
+					{Cmd: "npm test", Dir: "tests", Split: "horizontal"},
+					{Cmd: "htop", Dir: "/tmp"},
+				},
+			},
+		},
+		Options: map[string]interface{}{
+			"mouse":      true,
+			"base-index": 1,
+		},
+		Env: map[string]string{
+			"NODE_ENV": "development",
+		},
+	}
+}
+
+func TestLaunch(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+
+	if err := client.Launch(context.Background(), launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	session := server.session("test")
+	if session == nil {
+		t.Fatal("Launch() did not create session")
+	}
+
+	if session.options["mouse"] != "on" || session.options["base-index"] != "1" {
+		t.Errorf("session options = %v", session.options)
+	}
+
+	if session.env["NODE_ENV"] != "development" {
+		t.Errorf("session env = %v", session.env)
+	}
+
+	if len(session.windows) != 2 {
+		t.Fatalf("window count = %d, want 2", len(session.windows))
+	}
+
+	editor := session.windows[0]
+	if editor.name != "editor" || editor.layout != "main-vertical" || len(editor.panes) != 2 {
+		t.Errorf("editor window = %+v", editor)
+	}
+	if editor.options["@hive-layout"] != "main-vertical" {
+		t.Errorf("editor @hive-layout = %q", editor.options["@hive-layout"])
+	}
+	if editor.panes[0].command != "nvim" {
+		t.Errorf("editor pane 0 command = %q, want nvim", editor.panes[0].command)
+	}
+
+	api := session.windows[1]
+	wantDirs := []string{"/srv/app/api", "/srv/app/api/tests", "/tmp"}
+	for i, pane := range api.panes {
+		if pane.dir != wantDirs[i] {
+			t.Errorf("api pane %d dir = %q, want %q", i, pane.dir, wantDirs[i])
+		}
+	}
+
+	if session.active != 0 {
+		t.Errorf("active window = %d, want first window", session.active)
+	}
+}
+
+func TestLaunchExistingSession(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); !errors.Is(err, ErrSessionExists) {
+		t.Errorf("Launch() error = %v, want ErrSessionExists", err)
+	}
+}
+
+func TestLaunchSingleInvocation(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+
+	if err := client.Launch(context.Background(), launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// One call each to probe the version, check for an existing session
+	// and build it
+	if len(server.calls) != 3 {
+		t.Errorf("tmux invocations = %d, want 3", len(server.calls))
+	}
+}
+
+func TestLaunchStepsMatchLaunch(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+
+	opts := LaunchOptions{ConfigPath: "/srv/app/.hive.yaml", ConfigHash: "abc123", Version: "1.2.0"}
+	launched := time.Now()
+	if err := client.Launch(context.Background(), launchTestConfig(), opts); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// The build is the last invocation; drop the step markers in between
+	var ran [][]string
+	for _, command := range splitFakeCommands(server.calls[len(server.calls)-1]) {
+		if command[0] != "display-message" {
+			ran = append(ran, command)
+		}
+	}
+
+	steps := LaunchSteps(launchTestConfig(), Version{3, 4, ""}, opts, launched)
+	if len(ran) != len(steps) {
+		t.Fatalf("Launch() ran %d commands, LaunchSteps() lists %d", len(ran), len(steps))
+	}
+	for i, step := range steps {
+		// The launch time may have ticked over
+		if slices.Contains(step.Args, OptionLaunched) {
+			continue
+		}
+		if !slices.Equal(ran[i], step.Args) {
+			t.Errorf("command %d = %q, LaunchSteps() lists %q", i, ran[i], step.Args)
+		}
+	}
+
+	if got := server.session("test").options[OptionConfig]; got != opts.ConfigPath {
+		t.Errorf("%s = %q, want %q", OptionConfig, got, opts.ConfigPath)
+	}
+}
+
+func TestLaunchEnvironment(t *testing.T) {
+	tests := []struct {
+		version        string
+		wantSetEnvCall bool
+	}{
+		{"3.4", false},
+		{"3.2a", false},
+		{"3.1c", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			server := newFakeServer()
+			server.version = tt.version
+			client := server.client()
+
+			cfg := launchTestConfig()
+			cfg.Env = map[string]string{"APP_ENV": "dev", "PORT": "8080"}
+			if err := client.Launch(context.Background(), cfg, LaunchOptions{}); err != nil {
+				t.Fatalf("Launch() error = %v", err)
+			}
+
+			env := server.session("test").env
+			if env["APP_ENV"] != "dev" || env["PORT"] != "8080" {
+				t.Errorf("session env = %v", env)
+			}
+
+			build := strings.Join(server.calls[len(server.calls)-1], " ")
+			if got := strings.Contains(build, "set-environment"); got != tt.wantSetEnvCall {
+				t.Errorf("uses set-environment = %v, want %v", got, tt.wantSetEnvCall)
+			}
+		})
+	}
+}
+
+func TestLaunchPlanPaneSizes(t *testing.T) {
+	tests := []struct {
+		version Version
+		size    string
+		want    string
+	}{
+		{Version{3, 4, ""}, "30%", "-l 30%"},
+		{Version{3, 1, ""}, "30%", "-l 30%"},
+		{Version{3, 0, "a"}, "30%", "-p 30"},
+		{Version{2, 9, ""}, "12", "-l 12"},
+		{Version{}, "30%", "-l 30%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version.String()+" "+tt.size, func(t *testing.T) {
+			cfg := launchTestConfig()
+			cfg.Windows[1].Panes[1].Size = tt.size
+
+			for _, step := range LaunchPlan(cfg, tt.version) {
+				command := strings.Join(step.Args, " ")
+				if strings.HasPrefix(command, "split-window") && strings.Contains(command, "/srv/app/api/tests") {
+					if !strings.Contains(command, " "+tt.want+" ") {
+						t.Errorf("split = %q, want %q", command, tt.want)
+					}
+					return
+				}
+			}
+			t.Fatal("no split for the sized pane")
+		})
+	}
+}
+
+func TestLaunchPlanEscapesSeparators(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+
+	cfg := launchTestConfig()
+	cfg.Windows[0].Panes[0].Cmd = "make;"
+	if err := client.Launch(context.Background(), cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	pane := server.session("test").windows[0].panes[0]
+	if len(pane.sent) != 2 || pane.sent[0] != "make;" {
+		t.Errorf("keys sent to first pane = %q, want [make; Enter]", pane.sent)
+	}
+}
+
+func TestLaunchRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		keepPartial bool
+		wantSession bool
+	}{
+		{"rolls back", false, false},
+		{"keeps partial session", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer()
+			// The api window needs three panes
+			server.maxPanes = 2
+			client := server.client()
+
+			err := client.Launch(context.Background(), launchTestConfig(), LaunchOptions{KeepPartial: tt.keepPartial})
+
+			var launchErr *LaunchError
+			if !errors.As(err, &launchErr) {
+				t.Fatalf("Launch() error = %v, want LaunchError", err)
+			}
+			if launchErr.Description != "create pane 2 in window 'api'" {
+				t.Errorf("failed step = %q, want %q", launchErr.Description, "create pane 2 in window 'api'")
+			}
+			if !errors.Is(err, ErrPaneTooSmall) {
+				t.Errorf("Launch() error = %v, want ErrPaneTooSmall", err)
+			}
+			if launchErr.RolledBack == tt.keepPartial {
+				t.Errorf("RolledBack = %v", launchErr.RolledBack)
+			}
+			if got := server.session("test") != nil; got != tt.wantSession {
+				t.Errorf("session left behind = %v, want %v", got, tt.wantSession)
+			}
+		})
+	}
+}
+
+func TestLaunchRaceKeepsOtherSession(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+
+	// Another client creates the session between the existence check and
+	// the build
+	server.beforeCall = func(args []string) {
+		if slices.Contains(args, "new-session") && server.session("test") == nil {
+			server.sessions = append(server.sessions, &fakeSession{name: "test", options: map[string]string{}})
+		}
+	}
+
+	err := client.Launch(context.Background(), launchTestConfig(), LaunchOptions{})
+
+	var launchErr *LaunchError
+	if !errors.As(err, &launchErr) {
+		t.Fatalf("Launch() error = %v, want LaunchError", err)
+	}
+	if launchErr.Step != 0 || launchErr.RolledBack {
+		t.Errorf("failed step = %d, RolledBack = %v; want the create step, no rollback", launchErr.Step, launchErr.RolledBack)
+	}
+	if server.session("test") == nil {
+		t.Error("Launch() removed a session it did not create")
+	}
+}
+
+func TestLaunchCancelled(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := client.Version(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	err := client.Launch(ctx, launchTestConfig(), LaunchOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Launch() error = %v, want context.Canceled", err)
+	}
+	if server.session("test") != nil {
+		t.Error("Launch() left a session behind after cancellation")
+	}
+}
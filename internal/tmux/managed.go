@@ -0,0 +1,104 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Session options recording how hive launched a session
+const (
+	OptionConfig     = "@hive-config"
+	OptionConfigHash = "@hive-config-hash"
+	OptionVersion    = "@hive-version"
+	OptionLaunched   = "@hive-launched"
+)
+
+// ManagedSession is a running session launched by hive
+type ManagedSession struct {
+	Name       string
+	ConfigPath string
+	ConfigHash string
+	Version    string
+	Launched   time.Time // zero if not recorded
+	Windows    int
+	Panes      int
+	Attached   int // number of attached clients
+}
+
+// ListManagedSessions returns the sessions on the server that hive launched
+// from a config file, none if the server isn't running
+func (c *Client) ListManagedSessions(ctx context.Context) ([]ManagedSession, error) {
+	// Session names can't contain ":", but config paths can, so the path
+	// is read separately
+	format := "#{session_windows}:#{session_attached}:#{" + OptionLaunched + "}:#{" + OptionConfigHash + "}:#{" + OptionVersion + "}:#{session_name}"
+	output, err := c.run(ctx, "list-sessions", "-F", format)
+	if errors.Is(err, ErrNoServer) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var sessions []ManagedSession
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 6)
+		if len(parts) != 6 {
+			continue
+		}
+
+		session := ManagedSession{
+			Name:       parts[5],
+			ConfigHash: parts[3],
+			Version:    parts[4],
+		}
+		session.Windows, _ = strconv.Atoi(parts[0])
+		session.Attached, _ = strconv.Atoi(parts[1])
+		if seconds, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
+			session.Launched = time.Unix(seconds, 0)
+		}
+
+		// Every pane sees the session's options, so one line per pane gives
+		// both the config path and the pane count
+		panes, err := c.run(ctx, "list-panes", "-s", "-t", "="+session.Name, "-F", "#{"+OptionConfig+"}")
+		if errors.Is(err, ErrSessionNotFound) {
+			// Closed since the sessions were listed
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list panes of session '%s': %w", session.Name, err)
+		}
+		lines := strings.Split(panes, "\n")
+		if lines[0] == "" {
+			continue
+		}
+		session.ConfigPath = lines[0]
+		session.Panes = len(lines)
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// TagConfig records the config file a session matches, and a hash of its
+// contents, after hive brought the two in line again
+func (c *Client) TagConfig(ctx context.Context, sessionName, configPath, configHash string) error {
+	steps := []Step{
+		{
+			Description: fmt.Sprintf("record %s", OptionConfig),
+			Args:        []string{"set-option", "-t", sessionName, OptionConfig, configPath},
+		},
+		{
+			Description: fmt.Sprintf("record %s", OptionConfigHash),
+			Args:        []string{"set-option", "-t", sessionName, OptionConfigHash, configHash},
+		},
+	}
+	if _, err := c.runSequence(ctx, steps); err != nil {
+		return fmt.Errorf("failed to tag session: %w", err)
+	}
+	return nil
+}
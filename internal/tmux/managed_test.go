@@ -0,0 +1,61 @@
+package tmux
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestListManagedSessions(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if sessions, err := client.ListManagedSessions(ctx); err != nil || len(sessions) != 0 {
+		t.Fatalf("ListManagedSessions() without a server = %v, %v", sessions, err)
+	}
+
+	before := time.Now().Truncate(time.Second)
+	opts := LaunchOptions{ConfigPath: "/srv/app/.hive.yaml", ConfigHash: "abc123", Version: "1.2.0"}
+	if err := client.Launch(ctx, launchTestConfig(), opts); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Sessions launched without a config path aren't tagged
+	other := launchTestConfig()
+	other.Session.Name = "other"
+	if err := client.Launch(ctx, other, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	server.session("test").attached = 2
+
+	sessions, err := client.ListManagedSessions(ctx)
+	if err != nil {
+		t.Fatalf("ListManagedSessions() error = %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("ListManagedSessions() = %+v, want only the tagged session", sessions)
+	}
+
+	session := sessions[0]
+	if session.Name != "test" || session.ConfigPath != opts.ConfigPath || session.ConfigHash != opts.ConfigHash || session.Version != opts.Version {
+		t.Errorf("session = %+v, want the launch options recorded", session)
+	}
+	if session.Windows != 2 || session.Panes != 5 || session.Attached != 2 {
+		t.Errorf("windows = %d, panes = %d, attached = %d, want 2, 5, 2", session.Windows, session.Panes, session.Attached)
+	}
+	if session.Launched.Before(before) || session.Launched.After(time.Now()) {
+		t.Errorf("launched = %v, want the launch time", session.Launched)
+	}
+
+	if err := client.TagConfig(ctx, "test", "/srv/app/hive.yaml", "def456"); err != nil {
+		t.Fatalf("TagConfig() error = %v", err)
+	}
+	sessions, err = client.ListManagedSessions(ctx)
+	if err != nil {
+		t.Fatalf("ListManagedSessions() error = %v", err)
+	}
+	if session := sessions[0]; session.ConfigPath != "/srv/app/hive.yaml" || session.ConfigHash != "def456" || session.Version != opts.Version {
+		t.Errorf("session after TagConfig() = %+v, want the new config and the launch version", session)
+	}
+}
@@ -0,0 +1,179 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// environmentOptions are server options tmux derives from the environment it
+// starts in, which are never exported as changed
+var environmentOptions = []string{"editor"}
+
+// GetServerOptions returns the server options
+func (c *Client) GetServerOptions(ctx context.Context) (map[string]string, error) {
+	return c.showOptions(ctx, "-s")
+}
+
+// GetWindowOptions returns the options set locally on a window
+func (c *Client) GetWindowOptions(ctx context.Context, sessionName, windowIndex string) (map[string]string, error) {
+	return c.showOptions(ctx, "-w", "-t", fmt.Sprintf("%s:%s", sessionName, windowIndex))
+}
+
+// SetWindowOption sets a tmux window option
+func (c *Client) SetWindowOption(ctx context.Context, sessionName, windowIndex, key string, value interface{}) error {
+	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
+	if _, err := c.run(ctx, "set-option", "-w", "-t", target, key, config.FormatOptionValue(value)); err != nil {
+		return fmt.Errorf("failed to set window option: %w", err)
+	}
+	return nil
+}
+
+// SetServerOption sets a tmux server option
+func (c *Client) SetServerOption(ctx context.Context, key string, value interface{}) error {
+	if _, err := c.run(ctx, "set-option", "-s", key, config.FormatOptionValue(value)); err != nil {
+		return fmt.Errorf("failed to set server option: %w", err)
+	}
+	return nil
+}
+
+// defaultServerOptions returns the server options of a tmux started without
+// a configuration file. A throwaway server on a socket in a temporary
+// directory is started and killed within the single command, and the
+// directory removed after it.
+func (c *Client) defaultServerOptions(ctx context.Context) (map[string]string, error) {
+	dir, err := os.MkdirTemp("", "hive-defaults-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A copy keeps the executor and timeout; only the server differs
+	pristine := *c
+	pristine.SocketName = ""
+	pristine.SocketPath = filepath.Join(dir, "socket")
+	pristine.ConfigFile = os.DevNull
+
+	output, err := pristine.run(ctx, "start-server", ";", "show-options", "-s", ";", "kill-server")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default server options: %w", err)
+	}
+	return parseOptions(output), nil
+}
+
+// showOptions runs show-options and parses its output
+func (c *Client) showOptions(ctx context.Context, args ...string) (map[string]string, error) {
+	output, err := c.run(ctx, append([]string{"show-options"}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to show options: %w", err)
+	}
+	return parseOptions(output), nil
+}
+
+// parseOptions parses show-options output: one option per line, its name and
+// its value quoted the way tmux quotes command arguments. Array options are
+// listed per element, as "name[index] value".
+func parseOptions(output string) map[string]string {
+	options := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		options[key] = unquoteOption(value)
+	}
+	return options
+}
+
+// unquoteOption undoes the quoting of an option value
+func unquoteOption(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+
+	first, last := value[0], value[len(value)-1]
+	if first == '\'' && last == '\'' {
+		return value[1 : len(value)-1]
+	}
+	if first != '"' || last != '"' {
+		return value
+	}
+
+	// Double quoted values escape quotes, backslashes and $ with a
+	// backslash, and control characters C style or in octal
+	inner := value[1 : len(value)-1]
+	var sb strings.Builder
+	for i := 0; i < len(inner); i++ {
+		if inner[i] != '\\' || i+1 == len(inner) {
+			sb.WriteByte(inner[i])
+			continue
+		}
+
+		i++
+		switch inner[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'e':
+			sb.WriteByte('\033')
+		default:
+			if i+3 <= len(inner) {
+				if code, err := strconv.ParseUint(inner[i:i+3], 8, 8); err == nil {
+					sb.WriteByte(byte(code))
+					i += 2
+					continue
+				}
+			}
+			sb.WriteByte(inner[i])
+		}
+	}
+	return sb.String()
+}
+
+// nonDefaultOptions returns the options whose value differs from the defaults
+// they override, converted for the config. hive's own @hive-* options are
+// left out.
+func nonDefaultOptions(options, defaults map[string]string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range options {
+		if strings.HasPrefix(key, "@hive-") {
+			continue
+		}
+		if defaultValue, ok := defaults[key]; ok && defaultValue == value {
+			continue
+		}
+		result[key] = optionValue(value)
+	}
+	return result
+}
+
+// optionValue converts an option value to the type that reads best in YAML:
+// on/off as booleans and whole numbers as integers
+func optionValue(value string) interface{} {
+	switch value {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	if n, err := strconv.Atoi(value); err == nil && strconv.Itoa(n) == value {
+		return n
+	}
+	return value
+}
+
+// optionName strips the element index from an array option name, as in
+// "terminal-features[2]"
+func optionName(key string) string {
+	name, _, _ := strings.Cut(key, "[")
+	return name
+}
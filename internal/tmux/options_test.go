@@ -0,0 +1,140 @@
+package tmux
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+func TestParseOptions(t *testing.T) {
+	// Output of tmux 3.3a
+	output := strings.Join([]string{
+		`@mine "a b"`,
+		`status-left "[#S] \"x\" \$HOME \\o/"`,
+		`copy-command ''`,
+		`command-alias[2] "server-info=show-messages -JT"`,
+		`terminal-overrides`,
+		`word-separators "\t-"`,
+		`escape-time 10`,
+		``,
+	}, "\n")
+
+	want := map[string]string{
+		"@mine":              "a b",
+		"status-left":        `[#S] "x" $HOME \o/`,
+		"copy-command":       "",
+		"command-alias[2]":   "server-info=show-messages -JT",
+		"terminal-overrides": "",
+		"word-separators":    "\t-",
+		"escape-time":        "10",
+	}
+
+	if got := parseOptions(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOptions() = %q, want %q", got, want)
+	}
+}
+
+func TestNonDefaultOptions(t *testing.T) {
+	options := map[string]string{
+		"mouse":        "on",
+		"base-index":   "1",
+		"status":       "on",
+		"status-left":  "[#S] ",
+		"@hive-layout": "tiled",
+		"@project":     "api",
+	}
+	defaults := map[string]string{
+		"mouse":      "off",
+		"base-index": "0",
+		"status":     "on",
+	}
+
+	want := map[string]interface{}{
+		"mouse":       true,
+		"base-index":  1,
+		"status-left": "[#S] ",
+		"@project":    "api",
+	}
+
+	if got := nonDefaultOptions(options, defaults); !reflect.DeepEqual(got, want) {
+		t.Errorf("nonDefaultOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestExportOptions(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	cfg.Options["status"] = true
+	cfg.Options["status-left"] = `#[fg=green]"#S" $`
+	cfg.ServerOptions = map[string]interface{}{"escape-time": 10, "buffer-limit": 50}
+	cfg.Windows[1].Options = map[string]interface{}{"mode-keys": "vi", "remain-on-exit": true}
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	// Set by tmux from the environment of the user's server
+	server.serverOptions["editor"] = "nvim"
+	server.session("test").windows[0].options["automatic-rename"] = "off"
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	wantOptions := map[string]interface{}{"mouse": true, "base-index": 1, "status-left": `#[fg=green]"#S" $`}
+	if !reflect.DeepEqual(exported.Options, wantOptions) {
+		t.Errorf("Options = %v, want %v", exported.Options, wantOptions)
+	}
+	if want := map[string]interface{}{"escape-time": 10}; !reflect.DeepEqual(exported.ServerOptions, want) {
+		t.Errorf("ServerOptions = %v, want %v", exported.ServerOptions, want)
+	}
+	if exported.Windows[0].Options != nil {
+		t.Errorf("hive laid out window options = %v, want none", exported.Windows[0].Options)
+	}
+	if !reflect.DeepEqual(exported.Windows[1].Options, cfg.Windows[1].Options) {
+		t.Errorf("window options = %v, want %v", exported.Windows[1].Options, cfg.Windows[1].Options)
+	}
+
+	// Comparing with the config needs the options equal to their defaults
+	exported, err = client.ExportSession(ctx, "test", ExportOptions{KeepDefaults: true})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+	if changes := config.Diff(cfg, exported); len(changes) != 0 {
+		t.Errorf("exported config differs from launched config: %v", changes)
+	}
+}
+
+func TestDefaultServerOptionsClient(t *testing.T) {
+	var gotArgs []string
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			gotArgs = args
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		Timeout:    10 * time.Millisecond,
+		SocketPath: "/tmp/user.sock",
+	}
+
+	// The throwaway server gets its own socket but keeps the timeout
+	if _, err := client.defaultServerOptions(context.Background()); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("defaultServerOptions() error = %v, want timeout error", err)
+	}
+	args := strings.Join(gotArgs, " ")
+	if !strings.HasPrefix(args, "-S "+os.TempDir()) || !strings.Contains(args, " -f /dev/null start-server ") || !strings.HasSuffix(args, "; kill-server") {
+		t.Errorf("executor args = %q", args)
+	}
+	if _, err := os.Stat(filepath.Dir(gotArgs[1])); !os.IsNotExist(err) {
+		t.Errorf("socket directory left behind: %v", err)
+	}
+}
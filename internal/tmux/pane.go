@@ -1,14 +1,15 @@
 package tmux
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"strconv"
 	"strings"
 )
 
 // CreatePane creates a new pane by splitting an existing pane
 // Returns the pane ID of the newly created pane
-func CreatePane(sessionName, windowIndex, dir, split string) (string, error) {
+func (c *Client) CreatePane(ctx context.Context, sessionName, windowIndex, dir, split string) (string, error) {
 	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
 	args := []string{"split-window", "-t", target, "-P", "-F", "#{pane_id}"}
 
@@ -27,50 +28,57 @@ func CreatePane(sessionName, windowIndex, dir, split string) (string, error) {
 		args = append(args, "-c", dir)
 	}
 
-	cmd := exec.Command("tmux", args...)
-	output, err := cmd.Output()
+	paneID, err := c.run(ctx, args...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create pane: %w", err)
 	}
 
-	paneID := strings.TrimSpace(string(output))
 	return paneID, nil
 }
 
 // SendCommand sends a command to a pane
-func SendCommand(paneID, command string) error {
+func (c *Client) SendCommand(ctx context.Context, paneID, command string) error {
 	if command == "" {
 		return nil
 	}
 
 	// Send the command followed by Enter
-	cmd := exec.Command("tmux", "send-keys", "-t", paneID, command, "Enter")
-	if err := cmd.Run(); err != nil {
+	if _, err := c.run(ctx, "send-keys", "-t", paneID, command, "Enter"); err != nil {
 		return fmt.Errorf("failed to send command to pane: %w", err)
 	}
 
 	return nil
 }
 
+// KillPane kills a pane and the process running in it
+func (c *Client) KillPane(ctx context.Context, paneID string) error {
+	if _, err := c.run(ctx, "kill-pane", "-t", paneID); err != nil {
+		return fmt.Errorf("failed to kill pane: %w", err)
+	}
+	return nil
+}
+
 // ListPanes returns a list of panes in a window
-func ListPanes(sessionName, windowIndex string) ([]PaneInfo, error) {
+func (c *Client) ListPanes(ctx context.Context, sessionName, windowIndex string) ([]PaneInfo, error) {
 	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
-	cmd := exec.Command("tmux", "list-panes", "-t", target, "-F", "#{pane_id}:#{pane_current_path}:#{pane_current_command}")
-	output, err := cmd.Output()
+	// Paths go last since they may contain the separator
+	output, err := c.run(ctx, "list-panes", "-t", target, "-F", "#{pane_id}:#{pane_pid}:#{pane_current_command}:#{pane_current_path}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list panes: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lines := strings.Split(output, "\n")
 	panes := make([]PaneInfo, 0, len(lines))
 
 	for _, line := range lines {
-		parts := strings.SplitN(line, ":", 3)
-		if len(parts) == 3 {
+		parts := strings.SplitN(line, ":", 4)
+		if len(parts) == 4 {
+			pid, _ := strconv.Atoi(parts[1])
 			panes = append(panes, PaneInfo{
 				ID:      parts[0],
-				Dir:     parts[1],
+				PID:     pid,
 				Command: parts[2],
+				Dir:     parts[3],
 			})
 		}
 	}
@@ -81,6 +89,7 @@ func ListPanes(sessionName, windowIndex string) ([]PaneInfo, error) {
 // PaneInfo contains information about a tmux pane
 type PaneInfo struct {
 	ID      string
+	PID     int // process started in the pane, 0 if unknown
 	Dir     string
 	Command string
 }
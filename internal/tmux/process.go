@@ -0,0 +1,235 @@
+package tmux
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// procRoot is where the process tree is read from
+var procRoot = "/proc"
+
+// defaultShells are the programs a pane idles in at a prompt
+var defaultShells = []string{"sh", "bash", "zsh", "fish", "nu", "dash", "ksh", "mksh", "tcsh", "csh"}
+
+// shellSet returns the names of the programs treated as shells: the common
+// ones, the user's $SHELL and any extra names or paths
+func shellSet(extra []string) map[string]bool {
+	shells := make(map[string]bool)
+	for _, name := range defaultShells {
+		shells[name] = true
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		shells[filepath.Base(shell)] = true
+	}
+	for _, name := range extra {
+		shells[filepath.Base(name)] = true
+	}
+	return shells
+}
+
+// isShell reports whether a program name or path is one of the shells
+// Login shells are started with a leading "-", as in "-zsh"
+func isShell(shells map[string]bool, program string) bool {
+	return shells[strings.TrimPrefix(filepath.Base(program), "-")]
+}
+
+// paneCommand returns the command line running in the foreground of a pane,
+// or "" when the pane is idle at a shell prompt. Without a readable process
+// tree it falls back to the program name tmux reports.
+func paneCommand(pane PaneInfo, shells map[string]bool) string {
+	if pane.PID > 0 {
+		if args, ok := foregroundArgs(pane.PID, shells); ok {
+			if len(args) > 0 {
+				args[0] = programName(args[0])
+			}
+			return joinArgs(args)
+		}
+	}
+
+	if isShell(shells, pane.Command) {
+		return ""
+	}
+	return pane.Command
+}
+
+// programName shortens the path of a program to its name when $PATH finds
+// the same binary under it, so exported commands don't carry the install
+// paths of one machine
+func programName(program string) string {
+	if !filepath.IsAbs(program) {
+		return program
+	}
+
+	name := filepath.Base(program)
+	found, err := exec.LookPath(name)
+	if err != nil {
+		return program
+	}
+	foundInfo, err := os.Stat(found)
+	if err != nil {
+		return program
+	}
+	programInfo, err := os.Stat(program)
+	if err != nil || !os.SameFile(foundInfo, programInfo) {
+		return program
+	}
+	return name
+}
+
+// foregroundArgs walks from the pane process down through interactive shells
+// to the command the innermost one is running, nil if it is idle. ok is false
+// when the process tree can't be read.
+func foregroundArgs(pid int, shells map[string]bool) (args []string, ok bool) {
+	// The terminal's foreground process group leads to the process in front,
+	// the way tmux finds #{pane_current_command}
+	if leader := foregroundLeader(pid); leader > 0 {
+		args, err := processArgs(leader)
+		if err != nil {
+			return nil, false
+		}
+		if interactiveShell(shells, args) {
+			return nil, true
+		}
+		return args, true
+	}
+
+	// Otherwise follow the most recent children down through shells
+	args, err := processArgs(pid)
+	if err != nil {
+		return nil, false
+	}
+
+	for interactiveShell(shells, args) {
+		children, err := processChildren(pid)
+		if err != nil {
+			return nil, false
+		}
+		if len(children) == 0 {
+			return nil, true
+		}
+
+		// The most recently started child is the one in the foreground
+		pid = children[len(children)-1]
+		if args, err = processArgs(pid); err != nil {
+			return nil, false
+		}
+	}
+
+	return args, true
+}
+
+// interactiveShell reports whether a command line is a shell waiting at a
+// prompt rather than one running a script or a -c command
+func interactiveShell(shells map[string]bool, args []string) bool {
+	if !isShell(shells, args[0]) {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "-") || arg == "-c" {
+			return false
+		}
+	}
+	return true
+}
+
+// processArgs reads the command line of a process
+func processArgs(pid int) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	if args[0] == "" {
+		// Zombies and kernel threads have no command line
+		return nil, os.ErrNotExist
+	}
+	return args, nil
+}
+
+// processChildren returns the children of a process in the order they were
+// started. Kernels without the children file are handled by scanning the
+// parent of every process.
+func processChildren(pid int) ([]int, error) {
+	id := strconv.Itoa(pid)
+	data, err := os.ReadFile(filepath.Join(procRoot, id, "task", id, "children"))
+	if err == nil {
+		var children []int
+		for _, field := range strings.Fields(string(data)) {
+			if child, err := strconv.Atoi(field); err == nil {
+				children = append(children, child)
+			}
+		}
+		return children, nil
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries are sorted by name, not by pid
+	var children []int
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if ppid, _ := processStat(child); ppid == pid {
+			children = append(children, child)
+		}
+	}
+	slices.Sort(children)
+	return children, nil
+}
+
+// foregroundLeader returns the leader of the foreground process group on the
+// terminal of a process, if it is the process or one of its descendants
+func foregroundLeader(pid int) int {
+	_, leader := processStat(pid)
+	if leader <= 0 {
+		return 0
+	}
+
+	// Walk up from the leader, giving up on loops and unrelated trees
+	for current, depth := leader, 0; current > 1 && depth < 64; depth++ {
+		if current == pid {
+			return leader
+		}
+		current, _ = processStat(current)
+	}
+	return 0
+}
+
+// processStat reads the parent of a process and the foreground process group
+// of its terminal from its stat file, 0 if unknown
+func processStat(pid int) (ppid, tpgid int) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, 0
+	}
+
+	// The process name is in parentheses and may itself contain them:
+	// "PID (NAME) STATE PPID PGRP SESSION TTY TPGID ..."
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 6 {
+		return 0, 0
+	}
+	ppid, _ = strconv.Atoi(fields[1])
+	tpgid, _ = strconv.Atoi(fields[5])
+	return ppid, tpgid
+}
+
+// joinArgs joins a command line back into a string a shell splits the same way
+func joinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
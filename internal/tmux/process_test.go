@@ -0,0 +1,276 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeProc builds a /proc tree in a temporary directory and points procRoot
+// at it for the duration of the test
+type fakeProc struct {
+	t          *testing.T
+	root       string
+	children   bool // write task/PID/children files
+	foreground int  // foreground process group of the terminal, none if 0
+}
+
+func newFakeProc(t *testing.T, children bool) *fakeProc {
+	root := t.TempDir()
+	previous := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = previous })
+	return &fakeProc{t: t, root: root, children: children}
+}
+
+// add creates a process with the given parent and command line
+func (p *fakeProc) add(pid, ppid int, args ...string) {
+	p.t.Helper()
+	dir := filepath.Join(p.root, fmt.Sprint(pid))
+	task := filepath.Join(dir, "task", fmt.Sprint(pid))
+	if err := os.MkdirAll(task, 0755); err != nil {
+		p.t.Fatal(err)
+	}
+
+	name := filepath.Base(args[0])
+	files := map[string]string{
+		"cmdline": strings.Join(args, "\x00") + "\x00",
+		"stat":    fmt.Sprintf("%d (%s) S %d %d %d 34816 %d 4194560", pid, name, ppid, pid, pid, p.foreground),
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			p.t.Fatal(err)
+		}
+	}
+	if !p.children {
+		return
+	}
+
+	if err := os.WriteFile(filepath.Join(task, "children"), nil, 0644); err != nil {
+		p.t.Fatal(err)
+	}
+	// Parents outside the tree, like init, are left out
+	parent := filepath.Join(p.root, fmt.Sprint(ppid), "task", fmt.Sprint(ppid), "children")
+	if f, err := os.OpenFile(parent, os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+		fmt.Fprintf(f, "%d ", pid)
+		f.Close()
+	}
+}
+
+func TestPaneCommand(t *testing.T) {
+	t.Setenv("SHELL", "/usr/local/bin/elvish")
+
+	tests := []struct {
+		name       string
+		foreground int
+		procs      func(p *fakeProc)
+		shells     []string
+		pane       PaneInfo
+		want       string
+	}{
+		{
+			name:  "idle shell",
+			procs: func(p *fakeProc) { p.add(100, 1, "-zsh") },
+			pane:  PaneInfo{PID: 100, Command: "zsh"},
+			want:  "",
+		},
+		{
+			name: "command with arguments",
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "/bin/bash")
+				p.add(101, 100, "python", "manage.py", "runserver")
+			},
+			pane: PaneInfo{PID: 100, Command: "python"},
+			want: "python manage.py runserver",
+		},
+		{
+			name: "arguments that need quoting",
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "fish")
+				p.add(101, 100, "git", "commit", "-m", "it's done")
+			},
+			pane: PaneInfo{PID: 100, Command: "git"},
+			want: `git commit -m 'it'\''s done'`,
+		},
+		{
+			name: "nested shells",
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "bash")
+				p.add(101, 100, "nu")
+				p.add(102, 101, "dash")
+				p.add(103, 102, "npm", "run", "dev")
+				p.add(104, 103, "node", "server.js")
+			},
+			pane: PaneInfo{PID: 100, Command: "npm"},
+			want: "npm run dev",
+		},
+		{
+			name: "most recent child",
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "zsh")
+				p.add(101, 100, "sleep", "600")
+				p.add(102, 100, "htop")
+			},
+			pane: PaneInfo{PID: 100, Command: "htop"},
+			want: "htop",
+		},
+		{
+			name: "pane started with a command",
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "tail", "-f", "/var/log/syslog")
+			},
+			pane: PaneInfo{PID: 100, Command: "tail"},
+			want: "tail -f /var/log/syslog",
+		},
+		{
+			name: "shell running a script",
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "bash")
+				p.add(101, 100, "bash", "deploy.sh", "--prod")
+			},
+			pane: PaneInfo{PID: 100, Command: "bash"},
+			want: "bash deploy.sh --prod",
+		},
+		{
+			name:  "user's shell",
+			procs: func(p *fakeProc) { p.add(100, 1, "elvish") },
+			pane:  PaneInfo{PID: 100, Command: "elvish"},
+			want:  "",
+		},
+		{
+			name:   "configured shell",
+			procs:  func(p *fakeProc) { p.add(100, 1, "/opt/bin/xonsh", "--login") },
+			shells: []string{"xonsh"},
+			pane:   PaneInfo{PID: 100, Command: "xonsh"},
+			want:   "",
+		},
+		{
+			name:       "foreground process group",
+			foreground: 102,
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "bash")
+				p.add(101, 100, "npm", "run", "dev")
+				p.add(102, 100, "vim", "main.go")
+			},
+			pane: PaneInfo{PID: 100, Command: "vim"},
+			want: "vim main.go",
+		},
+		{
+			name:       "shell in the foreground while its startup files run",
+			foreground: 100,
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "bash")
+				p.add(101, 100, "python", "conda", "shell.bash", "hook")
+			},
+			pane: PaneInfo{PID: 100, Command: "bash"},
+			want: "",
+		},
+		{
+			name:       "foreground group of another pane",
+			foreground: 200,
+			procs: func(p *fakeProc) {
+				p.add(100, 1, "zsh")
+				p.add(101, 100, "htop")
+				p.add(200, 1, "zsh")
+			},
+			pane: PaneInfo{PID: 100, Command: "htop"},
+			want: "htop",
+		},
+		{
+			name:  "unreadable process falls back to tmux",
+			procs: func(p *fakeProc) {},
+			pane:  PaneInfo{PID: 100, Command: "vim"},
+			want:  "vim",
+		},
+		{
+			name:  "fallback recognizes shells",
+			procs: func(p *fakeProc) {},
+			pane:  PaneInfo{PID: 100, Command: "fish"},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, children := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s children=%v", tt.name, children), func(t *testing.T) {
+				procs := newFakeProc(t, children)
+				procs.foreground = tt.foreground
+				tt.procs(procs)
+
+				if got := paneCommand(tt.pane, shellSet(tt.shells)); got != tt.want {
+					t.Errorf("paneCommand() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestPaneCommandProgramPath(t *testing.T) {
+	bin := t.TempDir()
+	other := t.TempDir()
+	for _, path := range []string{filepath.Join(bin, "python3"), filepath.Join(bin, "lint"), filepath.Join(other, "lint")} {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{filepath.Join(bin, "python3"), "python3 -m http.server"},
+		// $PATH finds another binary of that name
+		{filepath.Join(other, "lint"), filepath.Join(other, "lint") + " -m http.server"},
+		// Not on $PATH at all
+		{"/opt/tool/serve", "/opt/tool/serve -m http.server"},
+		{"./serve", "./serve -m http.server"},
+	}
+
+	for _, tt := range tests {
+		procs := newFakeProc(t, true)
+		procs.add(100, 1, "bash")
+		procs.add(101, 100, tt.program, "-m", "http.server")
+
+		if got := paneCommand(PaneInfo{PID: 100, Command: "python3"}, shellSet(nil)); got != tt.want {
+			t.Errorf("paneCommand() running %s = %q, want %q", tt.program, got, tt.want)
+		}
+	}
+}
+
+func TestExportFullCommands(t *testing.T) {
+	procs := newFakeProc(t, true)
+	procs.add(500, 1, "zsh")
+	procs.add(501, 500, "npm", "run", "dev")
+	procs.add(600, 1, "zsh")
+
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	api := server.session("test").windows[1]
+	api.panes[0].pid = 500
+	api.panes[1].pid = 600
+
+	exported, err := client.ExportSession(ctx, "test", ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	panes := exported.Windows[1].Panes
+	if panes[0].Cmd != "npm run dev" {
+		t.Errorf("pane 0 cmd = %q, want %q", panes[0].Cmd, "npm run dev")
+	}
+	if panes[1].Cmd != "" {
+		t.Errorf("idle pane 1 cmd = %q, want none", panes[1].Cmd)
+	}
+	if panes[2].Cmd != "htop" {
+		t.Errorf("pane 2 without a pid cmd = %q, want htop", panes[2].Cmd)
+	}
+}
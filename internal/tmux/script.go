@@ -0,0 +1,58 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// Script renders a configuration as a standalone POSIX shell script that
+// builds the session with the same commands Launch would run on the given
+// tmux version. The script attaches to the session if it already exists
+// and removes a partially built session when a command fails.
+func (c *Client) Script(cfg *config.Config, version Version) string {
+	name := cfg.Session.Name
+	exact := "=" + name
+
+	hasSession := c.CommandLine(Step{Args: []string{"has-session", "-t", exact}})
+	killSession := c.CommandLine(Step{Args: []string{"kill-session", "-t", exact}})
+	switchClient := c.CommandLine(Step{Args: []string{"switch-client", "-t", exact}})
+	attachSession := c.CommandLine(Step{Args: []string{"attach-session", "-t", exact}})
+
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&sb, "# Builds the tmux session '%s'. Generated by hive; edit the hive config instead.\n", comment(name))
+	sb.WriteString("set -e\n\n")
+
+	sb.WriteString("attach() {\n")
+	sb.WriteString("\tif [ -n \"$TMUX\" ]; then\n")
+	fmt.Fprintf(&sb, "\t\texec %s\n", switchClient)
+	sb.WriteString("\tfi\n")
+	fmt.Fprintf(&sb, "\texec %s\n", attachSession)
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("# Attach instead of building the session twice\n")
+	fmt.Fprintf(&sb, "if %s 2>/dev/null; then\n", hasSession)
+	sb.WriteString("\tattach\n")
+	sb.WriteString("fi\n\n")
+
+	sb.WriteString("# Remove the partial session if any command fails\n")
+	fmt.Fprintf(&sb, "trap '%s 2>/dev/null' EXIT\n", strings.ReplaceAll(killSession, "'", `'\''`))
+
+	home, _ := os.UserHomeDir()
+	for _, step := range LaunchPlan(cfg, version) {
+		fmt.Fprintf(&sb, "\n# %s\n%s\n", comment(step.Description), c.commandLine(step, home))
+	}
+
+	sb.WriteString("\ntrap - EXIT\n")
+	sb.WriteString("attach\n")
+
+	return sb.String()
+}
+
+// comment keeps text on a single shell comment line
+func comment(text string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+}
@@ -0,0 +1,87 @@
+package tmux
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScriptSyntax(t *testing.T) {
+	script := (&Client{SocketName: "work"}).Script(launchTestConfig(), Version{3, 4, ""})
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Error("script does not start with a shebang")
+	}
+	if !strings.Contains(script, "if tmux -L work has-session -t =test 2>/dev/null; then") {
+		t.Error("script does not guard against an existing session")
+	}
+
+	cmd := exec.Command("sh", "-n")
+	cmd.Stdin = strings.NewReader(script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sh -n: %v\n%s", err, output)
+	}
+}
+
+func TestScriptRunsLaunchPlan(t *testing.T) {
+	// A stand-in tmux that logs its arguments, one invocation per block
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	stub := "#!/bin/sh\nprintf '%s\\n' \"$@\" >> \"$HIVE_LOG\"\necho --- >> \"$HIVE_LOG\"\n[ \"$1\" != has-session ]\n"
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := launchTestConfig()
+	cfg.Windows[0].Panes[0].Cmd = "echo 'it''s'; make;"
+	script := (&Client{}).Script(cfg, Version{3, 4, ""})
+
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Env = append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"), "HIVE_LOG="+log, "TMUX=")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, output)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSuffix(string(data), "---\n"), "---\n")
+
+	// The existence check, every plan step and the final attach
+	plan := LaunchPlan(cfg, Version{3, 4, ""})
+	if len(calls) != len(plan)+2 {
+		t.Fatalf("tmux invocations = %d, want %d", len(calls), len(plan)+2)
+	}
+
+	for i, step := range plan {
+		want := make([]string, len(step.Args))
+		for j, arg := range step.Args {
+			want[j] = escapeSeparator(arg)
+		}
+		if got := strings.TrimSuffix(calls[i+1], "\n"); got != strings.Join(want, "\n") {
+			t.Errorf("call %d = %q, want %q", i+1, got, strings.Join(want, "\n"))
+		}
+	}
+
+	if last := calls[len(calls)-1]; !strings.HasPrefix(last, "attach-session\n") {
+		t.Errorf("last call = %q, want attach-session", last)
+	}
+}
+
+func TestScriptHomeDirs(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+
+	cfg := launchTestConfig()
+	cfg.Session.BaseDir = "~/src/my app"
+	script := (&Client{}).Script(cfg, Version{3, 4, ""})
+
+	if !strings.Contains(script, `-c "$HOME"/'src/my app'`) {
+		t.Errorf("script does not write the base directory relative to $HOME:\n%s", script)
+	}
+	if strings.Contains(script, "/home/alice") {
+		t.Error("script contains the absolute home directory")
+	}
+}
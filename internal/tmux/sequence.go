@@ -0,0 +1,114 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// stepMarker prefixes the progress lines printed between tracked steps
+const stepMarker = "hive-step-done "
+
+// Step is a single tmux command in a command sequence
+type Step struct {
+	Description string
+	Args        []string
+}
+
+// runSequence runs all steps in a single tmux invocation, chaining the
+// commands with ";" the way `tmux cmd1 \; cmd2` does on the shell
+func (c *Client) runSequence(ctx context.Context, steps []Step) (string, error) {
+	if len(steps) == 0 {
+		return "", nil
+	}
+
+	var args []string
+	for i, step := range steps {
+		if i > 0 {
+			args = append(args, ";")
+		}
+		for _, arg := range step.Args {
+			args = append(args, escapeSeparator(arg))
+		}
+	}
+
+	return c.run(ctx, args...)
+}
+
+// runTracked runs all steps in a single tmux invocation like runSequence
+// and returns how many of them completed. A display-message after each step
+// prints a marker naming it, since tmux stops at the first failing command
+// without saying which one it was. The markers target the session, so the
+// first step must create it.
+func (c *Client) runTracked(ctx context.Context, sessionName string, steps []Step) (int, error) {
+	tracked := make([]Step, 0, 2*len(steps))
+	for i, step := range steps {
+		tracked = append(tracked, step)
+		if i < len(steps)-1 {
+			tracked = append(tracked, Step{
+				Description: fmt.Sprintf("mark step %d done", i),
+				Args:        []string{"display-message", "-p", "-t", sessionName, fmt.Sprintf("%s%d", stepMarker, i)},
+			})
+		}
+	}
+
+	output, err := c.runSequence(ctx, tracked)
+	if err == nil {
+		return len(steps), nil
+	}
+
+	completed := 0
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, stepMarker) {
+			completed++
+		}
+	}
+	return completed, err
+}
+
+// escapeSeparator keeps tmux from reading a trailing ";" in an argument as a
+// command separator
+func escapeSeparator(arg string) string {
+	if strings.HasSuffix(arg, ";") {
+		return strings.TrimSuffix(arg, ";") + "\\;"
+	}
+	return arg
+}
+
+// CommandLine renders a step as a standalone shell command that runs it on
+// the client's server
+func (c *Client) CommandLine(step Step) string {
+	return c.commandLine(step, "")
+}
+
+// commandLine renders a step like CommandLine. Directories under home, if
+// given, are written relative to $HOME so the command works for other users.
+func (c *Client) commandLine(step Step, home string) string {
+	words := []string{"tmux"}
+	for _, arg := range c.ServerArgs() {
+		words = append(words, ShellQuote(arg))
+	}
+
+	for i, arg := range step.Args {
+		word := ShellQuote(escapeSeparator(arg))
+		if home != "" && home != "/" && i > 0 && step.Args[i-1] == "-c" {
+			if arg == home {
+				word = `"$HOME"`
+			} else if rest, ok := strings.CutPrefix(arg, home+"/"); ok {
+				word = `"$HOME"/` + ShellQuote(rest)
+			}
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// ShellQuote quotes an argument for a POSIX shell
+func ShellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:%@=,+", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,78 @@
+package tmux
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunSequence(t *testing.T) {
+	var gotArgs []string
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			gotArgs = args
+			return nil, nil
+		}),
+	}
+
+	steps := []Step{
+		{Args: []string{"new-session", "-d", "-s", "dev"}},
+		{Args: []string{"send-keys", "-t", "dev:", "make; make test;", "Enter"}},
+		{Args: []string{"select-window", "-t", "dev:^"}},
+	}
+
+	if _, err := client.runSequence(context.Background(), steps); err != nil {
+		t.Fatalf("runSequence() error = %v", err)
+	}
+
+	want := `new-session -d -s dev ; send-keys -t dev: make; make test\; Enter ; select-window -t dev:^`
+	if got := strings.Join(gotArgs, " "); got != want {
+		t.Errorf("runSequence() args = %q, want %q", got, want)
+	}
+}
+
+func TestRunSequenceEmpty(t *testing.T) {
+	client := &Client{
+		Executor: funcExecutor(func(ctx context.Context, args []string) ([]byte, error) {
+			t.Error("executor should not run for an empty sequence")
+			return nil, nil
+		}),
+	}
+
+	if _, err := client.runSequence(context.Background(), nil); err != nil {
+		t.Errorf("runSequence() error = %v", err)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"new-session", "new-session"},
+		{"/srv/my-app", "/srv/my-app"},
+		{"", "''"},
+		{"npm run dev", "'npm run dev'"},
+		{"dev:^", "'dev:^'"},
+		{"#{pane_id}", "'#{pane_id}'"},
+		{"echo 'hi'", `'echo '\''hi'\'''`},
+		{"$HOME", "'$HOME'"},
+		{"~/src", "'~/src'"},
+	}
+
+	for _, tt := range tests {
+		if got := ShellQuote(tt.arg); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	client := &Client{SocketName: "work"}
+	step := Step{Args: []string{"send-keys", "-t", "dev:", "make; make test;", "Enter"}}
+
+	want := `tmux -L work send-keys -t dev: 'make; make test\;' Enter`
+	if got := client.CommandLine(step); got != want {
+		t.Errorf("CommandLine() = %s, want %s", got, want)
+	}
+}
@@ -1,19 +1,22 @@
 package tmux
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
 )
 
 // SessionExists checks if a tmux session with the given name exists
-func SessionExists(name string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", name)
-	return cmd.Run() == nil
+func (c *Client) SessionExists(ctx context.Context, name string) bool {
+	_, err := c.run(ctx, "has-session", "-t", name)
+	return err == nil
 }
 
 // CreateSession creates a new tmux session
-func CreateSession(name, baseDir string, options map[string]interface{}) error {
+func (c *Client) CreateSession(ctx context.Context, name, baseDir string, options map[string]interface{}) error {
 	args := []string{"new-session", "-d", "-s", name}
 
 	// Set the starting directory if provided
@@ -21,14 +24,13 @@ func CreateSession(name, baseDir string, options map[string]interface{}) error {
 		args = append(args, "-c", baseDir)
 	}
 
-	cmd := exec.Command("tmux", args...)
-	if err := cmd.Run(); err != nil {
+	if _, err := c.run(ctx, args...); err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// Apply session options
 	for key, value := range options {
-		if err := SetSessionOption(name, key, value); err != nil {
+		if err := c.SetSessionOption(ctx, name, key, value); err != nil {
 			return fmt.Errorf("failed to set option %s: %w", key, err)
 		}
 	}
@@ -37,36 +39,23 @@ func CreateSession(name, baseDir string, options map[string]interface{}) error {
 }
 
 // SetSessionOption sets a tmux session option
-func SetSessionOption(sessionName, key string, value interface{}) error {
-	var valueStr string
-	switch v := value.(type) {
-	case string:
-		valueStr = v
-	case bool:
-		if v {
-			valueStr = "on"
-		} else {
-			valueStr = "off"
-		}
-	case int:
-		valueStr = fmt.Sprintf("%d", v)
-	default:
-		valueStr = fmt.Sprintf("%v", v)
-	}
-
-	cmd := exec.Command("tmux", "set-option", "-t", sessionName, key, valueStr)
-	if err := cmd.Run(); err != nil {
+func (c *Client) SetSessionOption(ctx context.Context, sessionName, key string, value interface{}) error {
+	if _, err := c.run(ctx, "set-option", "-t", sessionName, key, config.FormatOptionValue(value)); err != nil {
 		return fmt.Errorf("failed to set option: %w", err)
 	}
 
 	return nil
 }
 
+// GetSessionOptions returns the options set locally on a session
+func (c *Client) GetSessionOptions(ctx context.Context, sessionName string) (map[string]string, error) {
+	return c.showOptions(ctx, "-t", sessionName)
+}
+
 // SetEnvVars sets environment variables for a tmux session
-func SetEnvVars(sessionName string, env map[string]string) error {
+func (c *Client) SetEnvVars(ctx context.Context, sessionName string, env map[string]string) error {
 	for key, value := range env {
-		cmd := exec.Command("tmux", "set-environment", "-t", sessionName, key, value)
-		if err := cmd.Run(); err != nil {
+		if _, err := c.run(ctx, "set-environment", "-t", sessionName, key, value); err != nil {
 			return fmt.Errorf("failed to set environment variable %s: %w", key, err)
 		}
 	}
@@ -74,22 +63,37 @@ func SetEnvVars(sessionName string, env map[string]string) error {
 }
 
 // KillSession kills a tmux session
-func KillSession(name string) error {
-	cmd := exec.Command("tmux", "kill-session", "-t", name)
-	if err := cmd.Run(); err != nil {
+func (c *Client) KillSession(ctx context.Context, name string) error {
+	if _, err := c.run(ctx, "kill-session", "-t", name); err != nil {
 		return fmt.Errorf("failed to kill session: %w", err)
 	}
 	return nil
 }
 
+// ListSessions returns the names of the sessions on the server, none if the
+// server isn't running
+func (c *Client) ListSessions(ctx context.Context) ([]string, error) {
+	output, err := c.run(ctx, "list-sessions", "-F", "#{session_name}")
+	if errors.Is(err, ErrNoServer) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
 // GetCurrentSession returns the name of the current tmux session
 // Returns empty string if not in a tmux session
-func GetCurrentSession() (string, error) {
-	cmd := exec.Command("tmux", "display-message", "-p", "#{session_name}")
-	output, err := cmd.Output()
+func (c *Client) GetCurrentSession(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "display-message", "-p", "#{session_name}")
 	if err != nil {
-		return "", fmt.Errorf("not in a tmux session or tmux is not running")
+		return "", fmt.Errorf("not in a tmux session or tmux is not running: %w", err)
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return output, nil
 }
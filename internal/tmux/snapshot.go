@@ -0,0 +1,118 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// SessionSnapshot is a session captured to be rebuilt later, after a reboot
+type SessionSnapshot struct {
+	// Config has the exact layout of every window, absolute directories,
+	// full commands and the unfiltered environment
+	Config *config.Config
+
+	// Scrollback holds the history of each pane with its colors, indexed
+	// like the windows and panes of Config
+	Scrollback [][]string
+}
+
+// SnapshotSession captures a session with the scrollback of its panes
+func (c *Client) SnapshotSession(ctx context.Context, sessionName string, opts ExportOptions) (*SessionSnapshot, error) {
+	opts.KeepEnv = true
+	opts.ExactLayouts = true
+	opts.BaseDir = "/"
+
+	cfg, panes, err := c.exportSession(ctx, sessionName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &SessionSnapshot{Config: cfg, Scrollback: make([][]string, len(panes))}
+	for i, windowPanes := range panes {
+		snapshot.Scrollback[i] = make([]string, len(windowPanes))
+		for j, pane := range windowPanes {
+			history, err := c.CapturePane(ctx, pane.ID)
+			if err != nil {
+				return nil, err
+			}
+			snapshot.Scrollback[i][j] = history
+		}
+	}
+
+	return snapshot, nil
+}
+
+// CapturePane returns the whole history and visible contents of a pane, with
+// the escape sequences of its colors and attributes. Wrapped lines are
+// joined and trailing blank lines left out.
+func (c *Client) CapturePane(ctx context.Context, paneID string) (string, error) {
+	output, err := c.run(ctx, "capture-pane", "-p", "-e", "-J", "-S", "-", "-t", paneID)
+	if err != nil {
+		return "", fmt.Errorf("failed to capture pane: %w", err)
+	}
+	return strings.TrimRight(output, "\n ") + "\n", nil
+}
+
+// RestoreSession rebuilds a captured session from its config. Panes whose
+// scrollback file is given start by printing it, then run their command in
+// a fresh shell.
+func (c *Client) RestoreSession(ctx context.Context, cfg *config.Config, scrollback func(window, pane int) string) error {
+	// Commands are started once the scrollback is back in place
+	bare := *cfg
+	bare.Windows = make([]config.WindowConfig, len(cfg.Windows))
+	for i, window := range cfg.Windows {
+		window.Panes = make([]config.PaneConfig, len(cfg.Windows[i].Panes))
+		for j, pane := range cfg.Windows[i].Panes {
+			pane.Cmd = ""
+			window.Panes[j] = pane
+		}
+		bare.Windows[i] = window
+	}
+
+	if err := c.Launch(ctx, &bare, LaunchOptions{}); err != nil {
+		return err
+	}
+
+	windows, err := c.ListWindows(ctx, cfg.Session.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list windows: %w", err)
+	}
+
+	var steps []Step
+	for i, window := range cfg.Windows {
+		if i >= len(windows) {
+			break
+		}
+		panes, err := c.ListPanes(ctx, cfg.Session.Name, windows[i].Index)
+		if err != nil {
+			return fmt.Errorf("failed to list panes for window %s: %w", window.Name, err)
+		}
+
+		for j, pane := range window.Panes {
+			if j >= len(panes) {
+				break
+			}
+			if file := scrollback(i, j); file != "" {
+				// The shell tmux starts is $SHELL in the pane's environment
+				steps = append(steps, Step{
+					Description: fmt.Sprintf("restore scrollback of pane %d in window '%s'", j, window.Name),
+					Args:        []string{"respawn-pane", "-k", "-t", panes[j].ID, "-c", panes[j].Dir, fmt.Sprintf("cat %s; exec \"$SHELL\"", ShellQuote(file))},
+				})
+			}
+			if pane.Cmd != "" {
+				steps = append(steps, Step{
+					Description: fmt.Sprintf("start pane %d in window '%s'", j, window.Name),
+					Args:        []string{"send-keys", "-t", panes[j].ID, pane.Cmd, "Enter"},
+				})
+			}
+		}
+	}
+
+	if _, err := c.runSequence(ctx, steps); err != nil {
+		return fmt.Errorf("failed to restore panes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+	"github.com/arch-err/tmux-hive/internal/layout"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	cfg := launchTestConfig()
+	if err := client.Launch(ctx, cfg, LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	for _, window := range server.session("test").windows {
+		for _, pane := range window.panes {
+			pane.history = fmt.Sprintf("history of %%%d\n\n", pane.id)
+		}
+	}
+
+	snapshot, err := client.SnapshotSession(ctx, "test", ExportOptions{})
+	if err != nil {
+		t.Fatalf("SnapshotSession() error = %v", err)
+	}
+
+	// Layouts are recorded exactly and directories absolutely
+	api := snapshot.Config.Windows[1]
+	if !layout.IsLayout(api.Layout) {
+		t.Errorf("api layout = %q, want a tmux layout string", api.Layout)
+	}
+	if dir := api.Panes[1].Dir; snapshot.Config.Session.BaseDir != "" || dir != "/srv/app/api/tests" {
+		t.Errorf("base_dir = %q, api pane 1 dir = %q, want absolute directories", snapshot.Config.Session.BaseDir, dir)
+	}
+	wantScrollback := [][]string{
+		{"history of %0\n", "history of %1\n"},
+		{"history of %2\n", "history of %3\n", "history of %4\n"},
+	}
+	if !reflect.DeepEqual(snapshot.Scrollback, wantScrollback) {
+		t.Errorf("Scrollback = %q, want %q", snapshot.Scrollback, wantScrollback)
+	}
+
+	if err := client.KillSession(ctx, "test"); err != nil {
+		t.Fatalf("KillSession() error = %v", err)
+	}
+
+	err = client.RestoreSession(ctx, snapshot.Config, func(window, pane int) string {
+		if window == 1 {
+			return fmt.Sprintf("/state/my snapshot/%d-%d.txt", window, pane)
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("RestoreSession() error = %v", err)
+	}
+
+	restored, err := client.ExportSession(ctx, "test", ExportOptions{KeepDefaults: true, KeepEnv: true})
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+	if changes := config.Diff(snapshot.Config, restored); len(changes) != 0 {
+		t.Errorf("restored session differs from the original: %v", changes)
+	}
+
+	windows := server.session("test").windows
+	if start := windows[0].panes[0].start; start != "" {
+		t.Errorf("editor pane 0 respawned with %q, want no scrollback", start)
+	}
+	pane := windows[1].panes[2]
+	if want := `cat '/state/my snapshot/1-2.txt'; exec "$SHELL"`; pane.start != want {
+		t.Errorf("api pane 2 respawned with %q, want %q", pane.start, want)
+	}
+	if want := []string{"htop", "Enter"}; !reflect.DeepEqual(pane.sent, want) {
+		t.Errorf("api pane 2 keys = %q, want %q", pane.sent, want)
+	}
+}
@@ -0,0 +1,338 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// SyncActionKind describes what a sync action does to the running session
+type SyncActionKind string
+
+const (
+	SyncAdd    SyncActionKind = "add"
+	SyncUpdate SyncActionKind = "update"
+	SyncRemove SyncActionKind = "remove"
+)
+
+// SyncAction is a single step needed to bring a session in line with its config
+type SyncAction struct {
+	Kind        SyncActionKind
+	Description string
+	apply       func(ctx context.Context) error
+}
+
+// Destructive reports whether the action kills running processes
+func (a SyncAction) Destructive() bool {
+	return a.Kind == SyncRemove
+}
+
+// SyncPlan is the ordered list of actions that reconciles a running session
+// with its configuration
+type SyncPlan struct {
+	Session string
+	Actions []SyncAction
+}
+
+// Empty reports whether the session already matches the config
+func (p *SyncPlan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// Destructive returns the actions that would kill running processes
+func (p *SyncPlan) Destructive() []SyncAction {
+	var actions []SyncAction
+	for _, action := range p.Actions {
+		if action.Destructive() {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// Apply runs the planned actions in order
+// Destructive actions are skipped unless includeDestructive is set
+func (p *SyncPlan) Apply(ctx context.Context, includeDestructive bool) error {
+	for _, action := range p.Actions {
+		if action.Destructive() && !includeDestructive {
+			continue
+		}
+		if err := action.apply(ctx); err != nil {
+			return fmt.Errorf("%s: %w", action.Description, err)
+		}
+	}
+	return nil
+}
+
+// PlanSync compares a configuration with its running session and returns the
+// actions needed to reconcile them. Windows are matched by name, panes by
+// position; anything that already matches is left untouched.
+func (c *Client) PlanSync(ctx context.Context, cfg *config.Config) (*SyncPlan, error) {
+	sessionName := cfg.Session.Name
+	if !c.SessionExists(ctx, sessionName) {
+		return nil, fmt.Errorf("session '%s' is not running", sessionName)
+	}
+
+	windows, err := c.ListWindows(ctx, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows: %w", err)
+	}
+
+	version, err := c.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SyncPlan{Session: sessionName}
+
+	// Get the base directory for resolving relative paths
+	baseDir := cfg.BaseDir()
+
+	// Match config windows to live windows by name
+	matched := make([]*WindowInfo, len(cfg.Windows))
+	used := make(map[string]bool)
+	for i, window := range cfg.Windows {
+		for j := range windows {
+			if !used[windows[j].Index] && windows[j].Name == window.Name {
+				matched[i] = &windows[j]
+				used[windows[j].Index] = true
+				break
+			}
+		}
+	}
+
+	// Unmatched windows at the same position are treated as renames
+	for i, window := range cfg.Windows {
+		if matched[i] != nil || i >= len(windows) || used[windows[i].Index] {
+			continue
+		}
+		live := windows[i]
+		matched[i] = &live
+		used[live.Index] = true
+
+		plan.add(SyncUpdate, fmt.Sprintf("rename window '%s' to '%s'", live.Name, window.Name), func(ctx context.Context) error {
+			return c.renameWindow(ctx, sessionName, live.Index, window.Name)
+		})
+	}
+
+	// Remove live windows that are no longer in the config
+	for _, live := range windows {
+		if used[live.Index] {
+			continue
+		}
+		index := live.Index
+		plan.add(SyncRemove, fmt.Sprintf("kill window '%s'", live.Name), func(ctx context.Context) error {
+			return c.KillWindow(ctx, sessionName, index)
+		})
+	}
+
+	for i, window := range cfg.Windows {
+		windowDir := config.ResolveDir(baseDir, window.Dir)
+
+		if matched[i] == nil {
+			plan.add(SyncAdd, fmt.Sprintf("create window '%s' with %d pane(s)", window.Name, len(window.Panes)), func(ctx context.Context) error {
+				dir := windowDir
+				if len(window.Panes) > 0 {
+					dir = config.ResolveDir(windowDir, window.Panes[0].Dir)
+				}
+				windowIndex, err := c.CreateWindow(ctx, sessionName, window.Name, dir, "")
+				if err != nil {
+					return err
+				}
+				target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
+				_, err = c.runSequence(ctx, windowSteps(target, windowDir, window, version))
+				return err
+			})
+			continue
+		}
+
+		if err := c.planPanes(ctx, plan, sessionName, *matched[i], windowDir, window, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.planOptions(ctx, plan, sessionName, cfg.Options); err != nil {
+		return nil, err
+	}
+
+	if err := c.planServerOptions(ctx, plan, cfg.ServerOptions); err != nil {
+		return nil, err
+	}
+
+	if err := c.planEnv(ctx, plan, sessionName, cfg.Env); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// planPanes adds the actions needed to reconcile the panes and layout of a
+// window that exists in both the config and the running session
+func (c *Client) planPanes(ctx context.Context, plan *SyncPlan, sessionName string, live WindowInfo, windowDir string, window config.WindowConfig, version Version) error {
+	panes, err := c.ListPanes(ctx, sessionName, live.Index)
+	if err != nil {
+		return fmt.Errorf("failed to list panes for window %s: %w", live.Name, err)
+	}
+
+	changed := false
+
+	// Add panes missing from the end of the window, each split off the pane
+	// before it like at launch, whichever pane is active
+	target := fmt.Sprintf("%s:%s", sessionName, live.Index)
+	last := target
+	if len(panes) > 0 {
+		last = panes[len(panes)-1].ID
+	}
+	for j := len(panes); j < len(window.Panes); j++ {
+		changed = true
+		plan.add(SyncAdd, fmt.Sprintf("add pane %d to window '%s'", j, window.Name), func(ctx context.Context) error {
+			steps := paneSteps(target, last, windowDir, window.Name, j, window.Panes[j], version)
+			// The ID of the new pane is where the next one splits off
+			steps[0].Args = append(steps[0].Args, "-P", "-F", "#{pane_id}")
+			output, err := c.runSequence(ctx, steps)
+			if err != nil {
+				return err
+			}
+			last = strings.TrimSpace(output)
+			return nil
+		})
+	}
+
+	// Kill surplus panes, starting from the last one
+	for j := len(panes) - 1; j >= len(window.Panes) && j > 0; j-- {
+		paneID := panes[j].ID
+		changed = true
+		plan.add(SyncRemove, fmt.Sprintf("kill pane %d (%s) in window '%s'", j, panes[j].Command, window.Name), func(ctx context.Context) error {
+			return c.KillPane(ctx, paneID)
+		})
+	}
+
+	if err := c.planWindowOptions(ctx, plan, sessionName, live, window); err != nil {
+		return err
+	}
+
+	if window.Layout != "" && (changed || live.HiveLayout != window.Layout || !keptLayout(live)) {
+		plan.add(SyncUpdate, fmt.Sprintf("set layout of window '%s' to %s", window.Name, window.Layout), func(ctx context.Context) error {
+			return c.SetWindowLayout(ctx, sessionName, live.Index, window.Layout)
+		})
+	}
+
+	return nil
+}
+
+// planOptions adds actions for session options whose live value differs
+func (c *Client) planOptions(ctx context.Context, plan *SyncPlan, sessionName string, options map[string]interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	live, err := c.GetSessionOptions(ctx, sessionName)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(options) {
+		value := config.FormatOptionValue(options[key])
+		if current, ok := live[key]; ok && current == value {
+			continue
+		}
+		plan.add(SyncUpdate, fmt.Sprintf("set option %s to %s", key, value), func(ctx context.Context) error {
+			return c.SetSessionOption(ctx, sessionName, key, value)
+		})
+	}
+
+	return nil
+}
+
+// planWindowOptions adds actions for window options whose live value differs
+func (c *Client) planWindowOptions(ctx context.Context, plan *SyncPlan, sessionName string, live WindowInfo, window config.WindowConfig) error {
+	if len(window.Options) == 0 {
+		return nil
+	}
+
+	current, err := c.GetWindowOptions(ctx, sessionName, live.Index)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(window.Options) {
+		value := config.FormatOptionValue(window.Options[key])
+		if currentValue, ok := current[key]; ok && currentValue == value {
+			continue
+		}
+		plan.add(SyncUpdate, fmt.Sprintf("set option %s of window '%s' to %s", key, window.Name, value), func(ctx context.Context) error {
+			return c.SetWindowOption(ctx, sessionName, live.Index, key, value)
+		})
+	}
+
+	return nil
+}
+
+// planServerOptions adds actions for server options whose live value differs
+func (c *Client) planServerOptions(ctx context.Context, plan *SyncPlan, options map[string]interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	live, err := c.GetServerOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(options) {
+		value := config.FormatOptionValue(options[key])
+		if current, ok := live[key]; ok && current == value {
+			continue
+		}
+		plan.add(SyncUpdate, fmt.Sprintf("set server option %s to %s", key, value), func(ctx context.Context) error {
+			return c.SetServerOption(ctx, key, value)
+		})
+	}
+
+	return nil
+}
+
+// planEnv adds actions for environment variables whose live value differs
+func (c *Client) planEnv(ctx context.Context, plan *SyncPlan, sessionName string, env map[string]string) error {
+	if len(env) == 0 {
+		return nil
+	}
+
+	live, err := c.getSessionEnv(ctx, sessionName)
+	if err != nil {
+		return fmt.Errorf("failed to read environment: %w", err)
+	}
+
+	for _, key := range sortedKeys(env) {
+		value := env[key]
+		if current, ok := live[key]; ok && current == value {
+			continue
+		}
+		plan.add(SyncUpdate, fmt.Sprintf("set environment %s", key), func(ctx context.Context) error {
+			return c.SetEnvVars(ctx, sessionName, map[string]string{key: value})
+		})
+	}
+
+	return nil
+}
+
+func (p *SyncPlan) add(kind SyncActionKind, description string, apply func(ctx context.Context) error) {
+	p.Actions = append(p.Actions, SyncAction{
+		Kind:        kind,
+		Description: description,
+		apply:       apply,
+	})
+}
+
+// sortedKeys returns the keys of a map in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+package tmux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+func TestPlanSyncInSync(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	plan, err := client.PlanSync(ctx, launchTestConfig())
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+
+	if !plan.Empty() {
+		for _, action := range plan.Actions {
+			t.Errorf("unexpected action: %s", action.Description)
+		}
+	}
+}
+
+func TestPlanSyncChanges(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	editorPane := server.session("test").windows[0].panes[0]
+
+	cfg := launchTestConfig()
+	cfg.Windows[0].Layout = "tiled"
+	cfg.Windows[0].Panes = append(cfg.Windows[0].Panes, config.PaneConfig{Cmd: "htop"})
+	cfg.Windows[1].Name = "backend"
+	cfg.Windows[1].Panes = cfg.Windows[1].Panes[:1]
+	cfg.Windows = append(cfg.Windows, config.WindowConfig{
+		Name:  "logs",
+		Panes: []config.PaneConfig{{Cmd: "tail -f log"}},
+	})
+	cfg.Options["history-limit"] = 1000
+	cfg.ServerOptions = map[string]interface{}{"escape-time": 10}
+	cfg.Windows[0].Options = map[string]interface{}{"mode-keys": "vi"}
+
+	plan, err := client.PlanSync(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+
+	if got := len(plan.Destructive()); got != 2 {
+		t.Errorf("destructive actions = %d, want 2", got)
+	}
+
+	// Apply without removals first: nothing may be killed
+	if err := plan.Apply(ctx, false); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	session := server.session("test")
+	if len(session.windows) != 3 {
+		t.Fatalf("window count = %d, want 3", len(session.windows))
+	}
+	if session.windows[1].name != "backend" || len(session.windows[1].panes) != 3 {
+		t.Errorf("renamed window = %q with %d panes", session.windows[1].name, len(session.windows[1].panes))
+	}
+	if session.windows[0].panes[0] != editorPane || editorPane.command != "nvim" {
+		t.Error("unchanged pane was replaced")
+	}
+	if session.windows[0].layout != "tiled" || len(session.windows[0].panes) != 3 {
+		t.Errorf("editor window layout = %q with %d panes", session.windows[0].layout, len(session.windows[0].panes))
+	}
+	if session.options["history-limit"] != "1000" {
+		t.Errorf("history-limit = %q, want 1000", session.options["history-limit"])
+	}
+	if server.serverOptions["escape-time"] != "10" {
+		t.Errorf("escape-time = %q, want 10", server.serverOptions["escape-time"])
+	}
+	if session.windows[0].options["mode-keys"] != "vi" {
+		t.Errorf("editor mode-keys = %q, want vi", session.windows[0].options["mode-keys"])
+	}
+
+	// Now allow removals
+	plan, err = client.PlanSync(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+	if err := plan.Apply(ctx, true); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := len(session.windows[1].panes); got != 1 {
+		t.Errorf("backend panes after sync = %d, want 1", got)
+	}
+
+	plan, err = client.PlanSync(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+	if !plan.Empty() {
+		for _, action := range plan.Actions {
+			t.Errorf("action left after sync: %s", action.Description)
+		}
+	}
+}
+
+func TestPlanSyncActions(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	cfg := launchTestConfig()
+	cfg.Windows[0].Name = "code"
+	cfg.Windows[1].Panes = cfg.Windows[1].Panes[:2]
+	cfg.Windows = append(cfg.Windows, config.WindowConfig{
+		Name:  "logs",
+		Panes: []config.PaneConfig{{Cmd: "tail -f log"}},
+	})
+	cfg.Env["DEBUG"] = "1"
+
+	plan, err := client.PlanSync(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+
+	want := []struct {
+		kind        SyncActionKind
+		description string
+	}{
+		{SyncUpdate, "rename window 'editor' to 'code'"},
+		{SyncRemove, "kill pane 2 (htop) in window 'api'"},
+		{SyncAdd, "create window 'logs' with 1 pane(s)"},
+		{SyncUpdate, "set environment DEBUG"},
+	}
+	if len(plan.Actions) != len(want) {
+		for _, action := range plan.Actions {
+			t.Logf("action: %s %s", action.Kind, action.Description)
+		}
+		t.Fatalf("actions = %d, want %d", len(plan.Actions), len(want))
+	}
+	for i, action := range plan.Actions {
+		if action.Kind != want[i].kind || action.Description != want[i].description {
+			t.Errorf("action %d = %s %q, want %s %q", i, action.Kind, action.Description, want[i].kind, want[i].description)
+		}
+	}
+	if got := len(plan.Destructive()); got != 1 {
+		t.Errorf("destructive actions = %d, want 1", got)
+	}
+
+	// Planning changes nothing
+	if session := server.session("test"); session.windows[0].name != "editor" || len(session.windows) != 2 {
+		t.Error("PlanSync() changed the session")
+	}
+}
+
+func TestPlanSyncAddsPanesAfterLast(t *testing.T) {
+	server := newFakeServer()
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.Launch(ctx, launchTestConfig(), LaunchOptions{}); err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	// The user moved to the first pane
+	editor := server.session("test").windows[0]
+	editor.active = 0
+
+	cfg := launchTestConfig()
+	cfg.Windows[0].Panes = append(cfg.Windows[0].Panes, config.PaneConfig{Cmd: "htop"}, config.PaneConfig{Cmd: "lazygit"})
+
+	plan, err := client.PlanSync(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync() error = %v", err)
+	}
+	if err := plan.Apply(ctx, false); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	var commands []string
+	for _, pane := range editor.panes {
+		commands = append(commands, pane.command)
+	}
+	if len(commands) != 4 || commands[0] != "nvim" || commands[2] != "htop" || commands[3] != "lazygit" {
+		t.Errorf("pane commands = %q, want the new panes after the existing ones", commands)
+	}
+}
+
+func TestPlanSyncMissingSession(t *testing.T) {
+	client := newFakeServer().client()
+
+	if _, err := client.PlanSync(context.Background(), launchTestConfig()); err == nil {
+		t.Error("PlanSync() should fail when the session is not running")
+	}
+}
@@ -0,0 +1,191 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+// Version is a tmux release such as 3.3a
+// The zero value is an unknown version, e.g. a build from master, and is
+// assumed to support every feature
+type Version struct {
+	Major  int
+	Minor  int
+	Suffix string // patch letter, "a" in 3.3a
+}
+
+// ParseVersion parses the output of tmux -V, with or without the leading
+// "tmux". Development builds ("next-3.4") count as the release they lead to;
+// builds without a version number ("master", "openbsd-7.4") parse as unknown.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "tmux"))
+	if s == "" {
+		return Version{}, fmt.Errorf("empty tmux version")
+	}
+
+	if rest, ok := strings.CutPrefix(s, "next-"); ok {
+		s = rest
+	} else if s == "master" || strings.HasPrefix(s, "openbsd-") {
+		return Version{}, nil
+	}
+
+	major, rest, ok := strings.Cut(s, ".")
+	if !ok {
+		return Version{}, fmt.Errorf("invalid tmux version %q", s)
+	}
+
+	digits := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		digits = len(rest)
+	}
+
+	var v Version
+	var err error
+	if v.Major, err = strconv.Atoi(major); err != nil {
+		return Version{}, fmt.Errorf("invalid tmux version %q", s)
+	}
+	if v.Minor, err = strconv.Atoi(rest[:digits]); err != nil {
+		return Version{}, fmt.Errorf("invalid tmux version %q", s)
+	}
+	v.Suffix = rest[digits:]
+
+	return v, nil
+}
+
+// Unknown reports whether the version could not be determined
+func (v Version) Unknown() bool {
+	return v == Version{}
+}
+
+// AtLeast reports whether v is the same release as min or newer
+// An unknown version is assumed to be newer than any release
+func (v Version) AtLeast(min Version) bool {
+	if v.Unknown() {
+		return true
+	}
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Suffix >= min.Suffix
+}
+
+func (v Version) String() string {
+	if v.Unknown() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d%s", v.Major, v.Minor, v.Suffix)
+}
+
+// Feature is a tmux capability hive may rely on
+type Feature string
+
+const (
+	// FeatureSplitPercent is split-window -l with a percentage
+	FeatureSplitPercent Feature = "split-window -l N%"
+	// FeatureNewSessionEnv is new-session -e
+	FeatureNewSessionEnv Feature = "new-session -e"
+)
+
+// features maps each feature to the first release that has it
+var features = map[Feature]Version{
+	FeatureSplitPercent:  {Major: 3, Minor: 1},
+	FeatureNewSessionEnv: {Major: 3, Minor: 2},
+}
+
+// optionVersions maps options to the first release that has them
+// Options missing from the table are assumed to be available everywhere
+var optionVersions = map[string]Version{
+	"mouse":                  {Major: 2, Minor: 1},
+	"focus-events":           {Major: 1, Minor: 9},
+	"renumber-windows":       {Major: 1, Minor: 7},
+	"set-titles-string":      {Major: 1, Minor: 7},
+	"status-position":        {Major: 1, Minor: 7},
+	"pane-border-status":     {Major: 2, Minor: 3},
+	"pane-border-format":     {Major: 2, Minor: 3},
+	"word-separators":        {Major: 1, Minor: 6},
+	"extended-keys":          {Major: 3, Minor: 2},
+	"copy-command":           {Major: 3, Minor: 2},
+	"pane-border-lines":      {Major: 3, Minor: 2},
+	"pane-border-indicators": {Major: 3, Minor: 3},
+	"popup-border-lines":     {Major: 3, Minor: 3},
+	"allow-passthrough":      {Major: 3, Minor: 3},
+	"menu-style":             {Major: 3, Minor: 4},
+}
+
+// Supports reports whether the version has a feature
+func (v Version) Supports(feature Feature) bool {
+	required, ok := features[feature]
+	return !ok || v.AtLeast(required)
+}
+
+// Version returns the version of the tmux binary the client runs
+// The result is cached for the lifetime of the client
+func (c *Client) Version(ctx context.Context) (Version, error) {
+	if c.version != nil {
+		return *c.version, nil
+	}
+
+	output, err := c.run(ctx, "-V")
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to get tmux version: %w", err)
+	}
+
+	version, err := ParseVersion(output)
+	if err != nil {
+		return Version{}, err
+	}
+
+	c.version = &version
+	return version, nil
+}
+
+// Preflight checks that the installed tmux can run a configuration
+// Features that can be downgraded are not reported
+func (c *Client) Preflight(ctx context.Context, cfg *config.Config) error {
+	version, err := c.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if errors := CheckSupport(cfg, version); len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+// CheckSupport returns the parts of a configuration the given tmux version
+// cannot run
+func CheckSupport(cfg *config.Config, version Version) config.ValidationErrors {
+	var errors config.ValidationErrors
+
+	errors = append(errors, checkOptions("options", cfg.Options, version)...)
+	errors = append(errors, checkOptions("server_options", cfg.ServerOptions, version)...)
+	for i, window := range cfg.Windows {
+		errors = append(errors, checkOptions(fmt.Sprintf("windows[%d].options", i), window.Options, version)...)
+	}
+
+	return errors
+}
+
+// checkOptions returns the options the given tmux version doesn't have
+func checkOptions(field string, options map[string]interface{}, version Version) config.ValidationErrors {
+	var errors config.ValidationErrors
+
+	for _, key := range sortedKeys(options) {
+		if required, ok := optionVersions[optionName(key)]; ok && !version.AtLeast(required) {
+			errors = append(errors, config.ValidationError{
+				Field:   field + "." + key,
+				Message: fmt.Sprintf("option '%s' requires tmux %s or later (found %s)", key, required, version),
+			})
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,161 @@
+package tmux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arch-err/tmux-hive/internal/config"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{"tmux 3.3a", Version{3, 3, "a"}, false},
+		{"tmux 3.4\n", Version{3, 4, ""}, false},
+		{"tmux 2.1", Version{2, 1, ""}, false},
+		{"3.2", Version{3, 2, ""}, false},
+		{"tmux next-3.5", Version{3, 5, ""}, false},
+		{"tmux master", Version{}, false},
+		{"tmux openbsd-7.4", Version{}, false},
+		{"tmux", Version{}, true},
+		{"tmux 3", Version{}, true},
+		{"tmux x.y", Version{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVersion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version Version
+		min     Version
+		want    bool
+	}{
+		{Version{3, 2, ""}, Version{3, 2, ""}, true},
+		{Version{3, 2, "a"}, Version{3, 2, ""}, true},
+		{Version{3, 1, "c"}, Version{3, 2, ""}, false},
+		{Version{2, 9, "a"}, Version{3, 0, ""}, false},
+		{Version{4, 0, ""}, Version{3, 9, ""}, true},
+		{Version{}, Version{3, 4, ""}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.version.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%v.AtLeast(%v) = %v, want %v", tt.version, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestVersionSupports(t *testing.T) {
+	tests := []struct {
+		version Version
+		feature Feature
+		want    bool
+	}{
+		{Version{3, 0, "a"}, FeatureSplitPercent, false},
+		{Version{3, 1, ""}, FeatureSplitPercent, true},
+		{Version{3, 1, "c"}, FeatureNewSessionEnv, false},
+		{Version{3, 2, ""}, FeatureNewSessionEnv, true},
+		{Version{}, FeatureNewSessionEnv, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.version.Supports(tt.feature); got != tt.want {
+			t.Errorf("%v.Supports(%s) = %v, want %v", tt.version, tt.feature, got, tt.want)
+		}
+	}
+}
+
+func TestClientVersionCached(t *testing.T) {
+	server := newFakeServer()
+	server.version = "3.3a"
+	client := server.client()
+
+	for i := 0; i < 2; i++ {
+		version, err := client.Version(context.Background())
+		if err != nil {
+			t.Fatalf("Version() error = %v", err)
+		}
+		if version != (Version{3, 3, "a"}) {
+			t.Errorf("Version() = %v, want 3.3a", version)
+		}
+	}
+
+	if len(server.calls) != 1 {
+		t.Errorf("tmux invocations = %d, want 1", len(server.calls))
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	cfg := &config.Config{
+		Session: config.SessionConfig{Name: "test"},
+		Options: map[string]interface{}{
+			"mouse":         true,
+			"extended-keys": "on",
+			"base-index":    1,
+		},
+		ServerOptions: map[string]interface{}{
+			"copy-command": "xclip -in",
+		},
+		Windows: []config.WindowConfig{{
+			Name:    "main",
+			Options: map[string]interface{}{"pane-border-lines": "heavy", "pane-border-format[0]": "#P"},
+		}},
+	}
+
+	tests := []struct {
+		version    string
+		wantFields []string
+	}{
+		{"3.4", nil},
+		{"3.1", []string{"options.extended-keys", "server_options.copy-command", "windows[0].options.pane-border-lines"}},
+		{"2.0", []string{
+			"options.extended-keys",
+			"options.mouse",
+			"server_options.copy-command",
+			"windows[0].options.pane-border-format[0]",
+			"windows[0].options.pane-border-lines",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			server := newFakeServer()
+			server.version = tt.version
+
+			err := server.client().Preflight(context.Background(), cfg)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("Preflight() error = %v", err)
+				}
+				return
+			}
+
+			errors, ok := err.(config.ValidationErrors)
+			if !ok {
+				t.Fatalf("Preflight() error = %v, want ValidationErrors", err)
+			}
+			if len(errors) != len(tt.wantFields) {
+				t.Fatalf("Preflight() errors = %v, want fields %v", errors, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if errors[i].Field != field {
+					t.Errorf("errors[%d].Field = %q, want %q", i, errors[i].Field, field)
+				}
+			}
+		})
+	}
+}
@@ -1,30 +1,28 @@
 package tmux
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
 // CreateWindow creates a new window in the specified session
-func CreateWindow(sessionName, windowName, dir, layout string) (string, error) {
-	args := []string{"new-window", "-t", sessionName, "-n", windowName, "-P", "-F", "#{window_index}"}
+// The window is created in the background and does not become current
+func (c *Client) CreateWindow(ctx context.Context, sessionName, windowName, dir, layout string) (string, error) {
+	args := []string{"new-window", "-d", "-t", sessionName, "-n", windowName, "-P", "-F", "#{window_index}"}
 
 	if dir != "" {
 		args = append(args, "-c", dir)
 	}
 
-	cmd := exec.Command("tmux", args...)
-	output, err := cmd.Output()
+	windowIndex, err := c.run(ctx, args...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create window: %w", err)
 	}
 
-	windowIndex := strings.TrimSpace(string(output))
-
 	// Set layout if specified
 	if layout != "" {
-		if err := SetWindowLayout(sessionName, windowIndex, layout); err != nil {
+		if err := c.SetWindowLayout(ctx, sessionName, windowIndex, layout); err != nil {
 			return "", err
 		}
 	}
@@ -33,33 +31,49 @@ func CreateWindow(sessionName, windowName, dir, layout string) (string, error) {
 }
 
 // SetWindowLayout sets the layout for a window
-func SetWindowLayout(sessionName, windowIndex, layout string) error {
+// The layout name is also recorded in the @hive-layout window option so
+// that sync can tell whether the configured layout changed
+func (c *Client) SetWindowLayout(ctx context.Context, sessionName, windowIndex, layout string) error {
 	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
-	cmd := exec.Command("tmux", "select-layout", "-t", target, layout)
-	if err := cmd.Run(); err != nil {
+	if _, err := c.run(ctx, "select-layout", "-t", target, layout); err != nil {
 		return fmt.Errorf("failed to set layout: %w", err)
 	}
+
+	if _, err := c.run(ctx, "set-option", "-w", "-t", target, "@hive-layout", layout); err != nil {
+		return fmt.Errorf("failed to record layout: %w", err)
+	}
+	return nil
+}
+
+// KillWindow kills a window and every pane in it
+func (c *Client) KillWindow(ctx context.Context, sessionName, windowIndex string) error {
+	target := fmt.Sprintf("%s:%s", sessionName, windowIndex)
+	if _, err := c.run(ctx, "kill-window", "-t", target); err != nil {
+		return fmt.Errorf("failed to kill window: %w", err)
+	}
 	return nil
 }
 
 // ListWindows returns a list of windows in a session
-func ListWindows(sessionName string) ([]WindowInfo, error) {
-	cmd := exec.Command("tmux", "list-windows", "-t", sessionName, "-F", "#{window_index}:#{window_name}:#{window_layout}")
-	output, err := cmd.Output()
+func (c *Client) ListWindows(ctx context.Context, sessionName string) ([]WindowInfo, error) {
+	// Names go last since they may contain the separator. Tabs would be
+	// safer but tmux prints them as "_" to clients without a UTF-8 locale.
+	output, err := c.run(ctx, "list-windows", "-t", sessionName, "-F", "#{window_index}:#{@hive-layout}:#{window_layout}:#{window_name}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list windows: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lines := strings.Split(output, "\n")
 	windows := make([]WindowInfo, 0, len(lines))
 
 	for _, line := range lines {
-		parts := strings.SplitN(line, ":", 3)
-		if len(parts) == 3 {
+		parts := strings.SplitN(line, ":", 4)
+		if len(parts) == 4 {
 			windows = append(windows, WindowInfo{
-				Index:  parts[0],
-				Name:   parts[1],
-				Layout: parts[2],
+				Index:      parts[0],
+				HiveLayout: parts[1],
+				Layout:     parts[2],
+				Name:       parts[3],
 			})
 		}
 	}
@@ -69,7 +83,8 @@ func ListWindows(sessionName string) ([]WindowInfo, error) {
 
 // WindowInfo contains information about a tmux window
 type WindowInfo struct {
-	Index  string
-	Name   string
-	Layout string
+	Index      string
+	Name       string
+	Layout     string
+	HiveLayout string // layout name last applied by hive, if any
 }